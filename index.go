@@ -1,26 +1,51 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"marathon_procrastination_bot/internal/env"
 	"net/http"
 	"time"
 
 	"github.com/go-telegram/bot/models"
+	"marathon_procrastination_bot/internal/admin"
+	"marathon_procrastination_bot/internal/auth"
+	"marathon_procrastination_bot/internal/logging"
 	"marathon_procrastination_bot/internal/storage"
 	"marathon_procrastination_bot/internal/telegram"
+	"marathon_procrastination_bot/internal/tracing"
+)
+
+const (
+	// replyFlushLimit and replyFlushTimeout bound delivery of queued messages
+	// after an update, so that the response to Telegram is not delayed.
+	replyFlushLimit   = 10
+	replyFlushTimeout = 5 * time.Second
 )
 
 func Handler(w http.ResponseWriter, r *http.Request) {
-	s, err := storage.New(r.Context())
+	logging.Setup()
+	tracing.Setup()
+	ctx := r.Context()
+	// the function may be frozen right after the response
+	defer func() {
+		if err := tracing.Flush(context.WithoutCancel(ctx)); err != nil {
+			slog.ErrorContext(ctx, "export spans", "error", err)
+		}
+	}()
+
+	s, err := storage.New(ctx)
 	if err != nil {
+		slog.ErrorContext(ctx, "open storage", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	agent, err := telegram.New(s)
 	if err != nil {
+		slog.ErrorContext(ctx, "create agent", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -36,87 +61,70 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	var (
-		update        models.Update
-		customRequest struct {
-			MagicNumber   int  `json:"magic_number,omitempty"`
-			RotateStats   bool `json:"rotate_stats,omitempty"`
-			NotifyUsers   bool `json:"notify_users,omitempty"`
-			NotifyWelcome bool `json:"notify_users,omitempty"`
-			MigrateSchema bool `json:"migrate_schema,omitempty"`
+		update models.Update
+		probe  struct {
+			MagicNumber int `json:"magic_number"`
 		}
 	)
 
-	err = json.Unmarshal(body, &customRequest)
+	err = json.Unmarshal(body, &probe)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if customRequest.MagicNumber != env.Magic() {
+	if probe.MagicNumber != env.Magic() {
+		if err := auth.Update(r, env.TelegramSecretToken()); err != nil {
+			slog.WarnContext(ctx, "reject update", "error", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
 		err = json.Unmarshal(body, &update)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		_, err = agent.Handle(r.Context(), agent.Bot(), &update)
+		// failures are logged by Handle
+		_, err = agent.Handle(ctx, agent.Bot(), &update)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		// e.g. the welcome message after /start; the rest is left to the outbox job
+		flushCtx, cancel := context.WithTimeout(ctx, replyFlushTimeout)
+		defer cancel()
+		if _, err := agent.Outbox().FlushBatch(flushCtx, replyFlushLimit); err != nil {
+			slog.ErrorContext(logging.With(ctx, logging.UpdateID, update.ID), "flush outbox", "error", err)
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	if customRequest.MigrateSchema {
-		if err := s.UpdateSchema(); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	if customRequest.RotateStats {
-		ids, err := s.UsersForRotate(r.Context(),
-			int32(time.Unix(int64(time.Now().UnixMilli()/1000/60/60)*60*60, 0).UTC().Hour()),
-		)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		for _, id := range ids {
-			if err := s.RotateUserStats(r.Context(), id); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
+	if err := auth.Admin(r, body, env.AdminToken()); err != nil {
+		slog.WarnContext(ctx, "reject admin request", "error", err)
+		respond(w, http.StatusUnauthorized, admin.Response{Version: admin.Version, Error: err.Error()})
+		return
 	}
 
-	if customRequest.NotifyUsers {
-		ids, err := s.UsersForNotification(r.Context())
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		for _, id := range ids {
-			if err := agent.PingUser(r.Context(), id); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
+	request, err := admin.Decode(body)
+	if err != nil {
+		respond(w, http.StatusBadRequest, admin.Response{Version: admin.Version, Error: err.Error()})
+		return
 	}
 
-	if customRequest.NotifyWelcome {
-		ids, err := s.UsersWithoutActivities(r.Context())
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		for _, id := range ids {
-			if err := agent.Welcome(r.Context(), id); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
+	ctx = logging.With(ctx, "action", request.Action, "dry_run", request.DryRun)
+	response, err := admin.New(s, s, agent, agent.Outbox()).Do(ctx, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "admin request failed", "error", err)
+		respond(w, http.StatusInternalServerError, response)
+		return
 	}
+	slog.InfoContext(ctx, "admin request done")
+	respond(w, http.StatusOK, response)
+}
 
-	w.WriteHeader(http.StatusOK)
+func respond(w http.ResponseWriter, status int, response admin.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"marathon_procrastination_bot/internal/metrics"
+	"marathon_procrastination_bot/internal/scheduler"
+)
+
+// Schema migrates the database.
+type Schema interface {
+	UpdateSchema() error
+	PendingMigrations() (versions []int64, _ error)
+}
+
+// Outbox delivers queued messages.
+type Outbox interface {
+	scheduler.Outbox
+	Pending(ctx context.Context) (int, error)
+}
+
+// Result is the outcome of a batch or of the outbox delivery.
+type Result struct {
+	scheduler.Summary
+	Error string `json:"error,omitempty"`
+}
+
+// Response is the structured result of the admin request.
+type Response struct {
+	Version int    `json:"version"`
+	Action  Action `json:"action,omitempty"`
+	DryRun  bool   `json:"dry_run"`
+	// Migrations are versions of applied migrations, or of pending ones on dry run.
+	Migrations []int64           `json:"migrations,omitempty"`
+	Batches    map[string]Result `json:"batches,omitempty"`
+	// Outbox counts sent messages, or messages due for delivery on dry run.
+	Outbox *Result `json:"outbox,omitempty"`
+	// Metrics are metrics of the process, which is not scraped when it runs as a function.
+	Metrics metrics.Snapshot `json:"metrics,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// Admin executes admin requests.
+type Admin struct {
+	schema  Schema
+	storage scheduler.Storage
+	agent   scheduler.Agent
+	outbox  Outbox
+}
+
+func New(schema Schema, s scheduler.Storage, a scheduler.Agent, o Outbox) *Admin {
+	return &Admin{
+		schema:  schema,
+		storage: s,
+		agent:   a,
+		outbox:  o,
+	}
+}
+
+// Do executes the decoded request. On failure the response still holds results of
+// everything that was done, and the error is set.
+func (a *Admin) Do(ctx context.Context, request Request) (response Response, _ error) {
+	response = Response{
+		Version: Version,
+		Action:  request.Action,
+		DryRun:  request.DryRun,
+	}
+	var err error
+	switch request.Action {
+	case MigrateSchema:
+		response.Migrations, err = a.schema.PendingMigrations()
+		if err == nil && !request.DryRun {
+			err = a.schema.UpdateSchema()
+		}
+	case RotateStats:
+		var params RotateParams
+		_ = request.params(&params)
+		batch := scheduler.Rotate(a.storage, a.agent)
+		if params.Hour != nil {
+			batch = scheduler.RotateAt(a.storage, a.agent, *params.Hour)
+		}
+		err = a.batches(ctx, &response, request.DryRun, job{"rotate", batch})
+	case NotifyUsers:
+		var params NotifyParams
+		_ = request.params(&params)
+		jobs := params.Jobs
+		if len(jobs) == 0 {
+			jobs = notifyJobs
+		}
+		constructors := map[string]func(scheduler.Storage, scheduler.Agent) scheduler.Batch{
+			"notify": scheduler.Notify,
+			"remind": scheduler.Remind,
+			"wake":   scheduler.Wake,
+			"digest": scheduler.Digest,
+		}
+		batches := make([]job, 0, len(jobs))
+		for _, name := range jobs {
+			batches = append(batches, job{name, constructors[name](a.storage, a.agent)})
+		}
+		err = a.batches(ctx, &response, request.DryRun, batches...)
+	case NotifyWelcome:
+		err = a.batches(ctx, &response, request.DryRun, job{"welcome", scheduler.Welcome(a.storage, a.agent)})
+	case FlushOutbox:
+		a.flush(ctx, &response, request.DryRun)
+	default:
+		err = ErrAction
+	}
+	if err := scheduler.Gauges(ctx, a.storage); err != nil {
+		slog.ErrorContext(ctx, "update gauges", "error", err)
+	}
+	snapshot, gatherErr := metrics.Gather()
+	if gatherErr != nil {
+		slog.ErrorContext(ctx, "gather metrics", "error", gatherErr)
+	}
+	response.Metrics = snapshot
+	if err != nil {
+		response.Error = err.Error()
+	}
+	return response, err
+}
+
+type job struct {
+	name  string
+	batch scheduler.Batch
+}
+
+// batches runs the batches in order and delivers messages they queued. Failures
+// of delivery are reported but do not fail the request: they are retried later.
+func (a *Admin) batches(ctx context.Context, response *Response, dryRun bool, jobs ...job) error {
+	response.Batches = make(map[string]Result, len(jobs))
+	var errs []error
+	for _, job := range jobs {
+		summary, err := job.batch(ctx, dryRun)
+		result := Result{Summary: summary}
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, err)
+		}
+		response.Batches[job.name] = result
+	}
+	a.flush(ctx, response, dryRun)
+	return errors.Join(errs...)
+}
+
+func (a *Admin) flush(ctx context.Context, response *Response, dryRun bool) {
+	var (
+		count int
+		err   error
+	)
+	if dryRun {
+		count, err = a.outbox.Pending(ctx)
+	} else {
+		count, err = a.outbox.Flush(ctx)
+	}
+	response.Outbox = &Result{Summary: scheduler.Summary{Processed: count}}
+	if err != nil {
+		response.Outbox.Error = err.Error()
+	}
+}
@@ -0,0 +1,114 @@
+// Package admin is the versioned API of admin requests to the function:
+// migrations, batch jobs and delivery of the outbox.
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Version is the current version of the admin API.
+const Version = 1
+
+// Action is what the admin request asks to do.
+type Action string
+
+const (
+	MigrateSchema Action = "migrate_schema"
+	RotateStats   Action = "rotate_stats"
+	NotifyUsers   Action = "notify_users"
+	NotifyWelcome Action = "notify_welcome"
+	FlushOutbox   Action = "flush_outbox"
+)
+
+// Jobs of NotifyUsers, all of them by default.
+var notifyJobs = []string{"notify", "remind", "wake", "digest"}
+
+var (
+	ErrMagic   = errors.New("missing admin magic number")
+	ErrVersion = errors.New("unsupported admin api version")
+	ErrAction  = errors.New("unknown admin action")
+	ErrParams  = errors.New("invalid admin params")
+)
+
+// Request is the admin request. The magic number tells it apart from Telegram updates.
+type Request struct {
+	MagicNumber int             `json:"magic_number"`
+	Version     int             `json:"version"`
+	Action      Action          `json:"action"`
+	DryRun      bool            `json:"dry_run,omitempty"`
+	Params      json.RawMessage `json:"params,omitempty"`
+}
+
+// RotateParams are params of RotateStats.
+type RotateParams struct {
+	// Hour rotates users with this rotation hour instead of the current UTC hour.
+	Hour *int32 `json:"hour,omitempty"`
+}
+
+// NotifyParams are params of NotifyUsers.
+type NotifyParams struct {
+	// Jobs is the subset of notify, remind, wake and digest to run. All of them if empty.
+	Jobs []string `json:"jobs,omitempty"`
+}
+
+// Decode parses the admin request, rejecting requests without the magic number,
+// unknown fields, versions, actions and invalid params.
+func Decode(body []byte) (request Request, _ error) {
+	if err := strict(body, &request); err != nil {
+		return request, err
+	}
+	if request.MagicNumber == 0 {
+		return request, ErrMagic
+	}
+	if request.Version != Version {
+		return request, fmt.Errorf("%w: %d", ErrVersion, request.Version)
+	}
+	switch request.Action {
+	case MigrateSchema, NotifyWelcome, FlushOutbox:
+		if err := request.params(&struct{}{}); err != nil {
+			return request, err
+		}
+	case RotateStats:
+		var params RotateParams
+		if err := request.params(&params); err != nil {
+			return request, err
+		}
+		if params.Hour != nil && (*params.Hour < 0 || *params.Hour > 23) {
+			return request, fmt.Errorf("%w: hour %d is out of 0..23", ErrParams, *params.Hour)
+		}
+	case NotifyUsers:
+		var params NotifyParams
+		if err := request.params(&params); err != nil {
+			return request, err
+		}
+		for _, job := range params.Jobs {
+			if !slices.Contains(notifyJobs, job) {
+				return request, fmt.Errorf("%w: unknown job %q", ErrParams, job)
+			}
+		}
+	default:
+		return request, fmt.Errorf("%w: %q", ErrAction, request.Action)
+	}
+	return request, nil
+}
+
+// params decodes params of the request into v. Absent params are zero.
+func (r Request) params(v any) error {
+	if len(r.Params) == 0 || string(r.Params) == "null" {
+		return nil
+	}
+	if err := strict(r.Params, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrParams, err)
+	}
+	return nil
+}
+
+func strict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Action
+		wantErr error
+	}{
+		{
+			name: "valid",
+			body: `{"magic_number":1,"version":1,"action":"notify_users","params":{"jobs":["remind"]},"dry_run":true}`,
+			want: NotifyUsers,
+		},
+		{
+			name:    "missing magic number",
+			body:    `{"version":1,"action":"flush_outbox"}`,
+			wantErr: ErrMagic,
+		},
+		{
+			name:    "version mismatch",
+			body:    `{"magic_number":1,"version":2,"action":"flush_outbox"}`,
+			wantErr: ErrVersion,
+		},
+		{
+			name:    "missing version",
+			body:    `{"magic_number":1,"action":"flush_outbox"}`,
+			wantErr: ErrVersion,
+		},
+		{
+			name:    "unknown action",
+			body:    `{"magic_number":1,"version":1,"action":"drop_tables"}`,
+			wantErr: ErrAction,
+		},
+		{
+			name:    "unknown param",
+			body:    `{"magic_number":1,"version":1,"action":"rotate_stats","params":{"hours":3}}`,
+			wantErr: ErrParams,
+		},
+		{
+			name:    "params of an action without params",
+			body:    `{"magic_number":1,"version":1,"action":"migrate_schema","params":{"hour":3}}`,
+			wantErr: ErrParams,
+		},
+		{
+			name:    "hour out of range",
+			body:    `{"magic_number":1,"version":1,"action":"rotate_stats","params":{"hour":24}}`,
+			wantErr: ErrParams,
+		},
+		{
+			name:    "unknown job",
+			body:    `{"magic_number":1,"version":1,"action":"notify_users","params":{"jobs":["spam"]}}`,
+			wantErr: ErrParams,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got.Action != tt.want {
+				t.Errorf("Decode() action = %q, want %q", got.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownField(t *testing.T) {
+	body := `{"magic_number":1,"version":1,"action":"flush_outbox","force":true}`
+	if _, err := Decode([]byte(body)); err == nil {
+		t.Error("Decode() accepts the unknown field")
+	}
+}
@@ -0,0 +1,76 @@
+// Package auth verifies that requests to the function come from Telegram or from the admin.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	// SecretTokenHeader carries the secret_token given to setWebhook in every update.
+	SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body keyed by the admin token.
+	SignatureHeader = "X-Signature-256"
+
+	bearerPrefix    = "Bearer "
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrNoSecretToken  = errors.New("telegram secret token is not configured")
+	ErrBadSecretToken = errors.New("invalid telegram secret token")
+	ErrNoAdminToken   = errors.New("admin token is not configured")
+	ErrUnsigned       = errors.New("admin request is neither signed nor bearer-authenticated")
+	ErrBadCredentials = errors.New("invalid admin credentials")
+)
+
+// Update checks the secret token of the webhook request. Without the secret
+// every update is rejected, as the webhook is public otherwise.
+func Update(r *http.Request, secret string) error {
+	if secret == "" {
+		return ErrNoSecretToken
+	}
+	if !equal(r.Header.Get(SecretTokenHeader), secret) {
+		return ErrBadSecretToken
+	}
+	return nil
+}
+
+// Admin checks that the request carries either the admin token as a bearer token
+// or the HMAC signature of the body keyed by the admin token.
+func Admin(r *http.Request, body []byte, token string) error {
+	if token == "" {
+		return ErrNoAdminToken
+	}
+	if signature := r.Header.Get(SignatureHeader); signature != "" {
+		sum, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+		if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+			return ErrBadCredentials
+		}
+		mac := hmac.New(sha256.New, []byte(token))
+		mac.Write(body)
+		if !hmac.Equal(sum, mac.Sum(nil)) {
+			return ErrBadCredentials
+		}
+		return nil
+	}
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if !strings.HasPrefix(authorization, bearerPrefix) ||
+			!equal(strings.TrimPrefix(authorization, bearerPrefix), token) {
+			return ErrBadCredentials
+		}
+		return nil
+	}
+	return ErrUnsigned
+}
+
+// equal compares secrets in constant time. Hashing first hides the length of the secret as well.
+func equal(got, want string) bool {
+	g, w := sha256.Sum256([]byte(got)), sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(g[:], w[:]) == 1
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sign(body, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(body))
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		secret string
+		want   error
+	}{
+		{"valid secret", "secret", "secret", nil},
+		{"wrong secret", "guess", "secret", ErrBadSecretToken},
+		{"missing secret", "", "secret", ErrBadSecretToken},
+		{"secret prefix", "secre", "secret", ErrBadSecretToken},
+		{"not configured", "", "", ErrNoSecretToken},
+		{"not configured with header", "secret", "", ErrNoSecretToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", nil)
+			if tt.header != "" {
+				r.Header.Set(SecretTokenHeader, tt.header)
+			}
+			if err := Update(r, tt.secret); !errors.Is(err, tt.want) {
+				t.Errorf("Update() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	const (
+		token = "token"
+		body  = `{"action":"rotate_stats"}`
+	)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		token   string
+		want    error
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer token"}, token, nil},
+		{"wrong bearer", map[string]string{"Authorization": "Bearer guess"}, token, ErrBadCredentials},
+		{"not bearer", map[string]string{"Authorization": "Basic token"}, token, ErrBadCredentials},
+		{"signature", map[string]string{SignatureHeader: sign(body, token)}, token, nil},
+		{"signature of another body", map[string]string{SignatureHeader: sign(body+" ", token)}, token, ErrBadCredentials},
+		{"signature by another key", map[string]string{SignatureHeader: sign(body, "guess")}, token, ErrBadCredentials},
+		{"signature without prefix", map[string]string{SignatureHeader: strings.TrimPrefix(sign(body, token), signaturePrefix)}, token, ErrBadCredentials},
+		{"signature not hex", map[string]string{SignatureHeader: signaturePrefix + "zz"}, token, ErrBadCredentials},
+		{"wrong signature over bearer", map[string]string{SignatureHeader: sign(body, "guess"), "Authorization": "Bearer token"}, token, ErrBadCredentials},
+		{"missing credentials", nil, token, ErrUnsigned},
+		{"not configured", map[string]string{"Authorization": "Bearer "}, "", ErrNoAdminToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			if err := Admin(r, []byte(body), tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Admin() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package conversation
+
+import (
+	"time"
+)
+
+// Flow is a multi-step dialog with the user.
+type Flow string
+
+const (
+	Create Flow = "create"
+	Rename Flow = "rename"
+	Target Flow = "target"
+	Import Flow = "import"
+)
+
+// State is a persisted position of the user in a flow.
+type State struct {
+	Flow Flow
+	// Arg is a flow specific argument, e.g. the activity being renamed.
+	Arg string
+	// PromptID is the message which asked the user for input.
+	PromptID int
+	Expires  time.Time
+}
+
+func New(flow Flow, arg string, timeout time.Duration) State {
+	return State{
+		Flow:    flow,
+		Arg:     arg,
+		Expires: time.Now().UTC().Add(timeout),
+	}
+}
+
+func (s State) Expired(now time.Time) bool {
+	return !now.Before(s.Expires)
+}
@@ -0,0 +1,164 @@
+package digest
+
+import (
+	"sort"
+	"time"
+
+	"marathon_procrastination_bot/internal/reminder"
+)
+
+// Kind is a period of the digest.
+type Kind int
+
+const (
+	Weekly Kind = iota
+	Monthly
+)
+
+// Off disables the weekly digest.
+const Off = -1
+
+// Settings is a per-user digest configuration. All hours are in UTC.
+type Settings struct {
+	RotateHour    int32
+	Hour          int32
+	Weekday       int32
+	Monthly       bool
+	WeeklySentTS  time.Time
+	MonthlySentTS time.Time
+}
+
+type Post struct {
+	Activity string
+	TS       time.Time
+}
+
+// Period is a half-open interval [From, To) of user days and the previous
+// interval [PrevFrom, From) of the same kind for comparison.
+type Period struct {
+	PrevFrom time.Time
+	From     time.Time
+	To       time.Time
+}
+
+// Due returns the digests which must be sent at now with their periods.
+func (s Settings) Due(now time.Time) map[Kind]Period {
+	due := make(map[Kind]Period, 2)
+	now = now.UTC()
+	if s.Weekday != Off {
+		at := time.Date(now.Year(), now.Month(), now.Day(), int(s.Hour), 0, 0, 0, time.UTC).
+			AddDate(0, 0, -((int(now.Weekday()) - int(s.Weekday) + 7) % 7))
+		if at.After(now) {
+			at = at.AddDate(0, 0, -7)
+		}
+		if s.WeeklySentTS.Before(at) {
+			to := reminder.DayStart(s.RotateHour, at)
+			due[Weekly] = Period{
+				PrevFrom: to.AddDate(0, 0, -14),
+				From:     to.AddDate(0, 0, -7),
+				To:       to,
+			}
+		}
+	}
+	if s.Monthly {
+		at := time.Date(now.Year(), now.Month(), 1, int(s.Hour), 0, 0, 0, time.UTC)
+		if at.After(now) {
+			at = at.AddDate(0, -1, 0)
+		}
+		if s.MonthlySentTS.Before(at) {
+			to := time.Date(at.Year(), at.Month(), 1, int(s.RotateHour), 0, 0, 0, time.UTC)
+			due[Monthly] = Period{
+				PrevFrom: to.AddDate(0, -2, 0),
+				From:     to.AddDate(0, -1, 0),
+				To:       to,
+			}
+		}
+	}
+	return due
+}
+
+type Activity struct {
+	Name string
+	// Days and PrevDays are the numbers of user days with posts
+	// in the period and in the previous period.
+	Days     int
+	PrevDays int
+	// StreakBefore and StreakAfter are the streaks at the beginning
+	// and at the end of the period.
+	StreakBefore int
+	StreakAfter  int
+}
+
+type Summary struct {
+	Period     Period
+	Days       int
+	Activities []Activity
+	// BestWeekday is the day of week with the most posts, valid if HasBestWeekday.
+	BestWeekday    time.Weekday
+	HasBestWeekday bool
+	Total          int
+	PrevTotal      int
+}
+
+// Summarize builds the digest of posts for the period. Posts must cover both
+// the period and the previous period.
+func Summarize(rotateHour int32, period Period, activities []string, posts []Post) Summary {
+	summary := Summary{
+		Period:     period,
+		Days:       int(period.To.Sub(period.From).Hours()+12) / 24,
+		Activities: make([]Activity, 0, len(activities)),
+	}
+	var (
+		days     = make(map[string]map[time.Time]bool, len(activities))
+		weekdays [7]int
+	)
+	for _, post := range posts {
+		day := reminder.DayStart(rotateHour, post.TS)
+		if days[post.Activity] == nil {
+			days[post.Activity] = make(map[time.Time]bool)
+		}
+		if days[post.Activity][day] {
+			continue
+		}
+		days[post.Activity][day] = true
+		if !day.Before(period.From) && day.Before(period.To) {
+			weekdays[day.Add(12*time.Hour).Weekday()]++
+		}
+	}
+	for _, name := range activities {
+		activity := Activity{
+			Name:         name,
+			StreakBefore: streak(days[name], period.From),
+			StreakAfter:  streak(days[name], period.To),
+		}
+		for day := range days[name] {
+			switch {
+			case !day.Before(period.From) && day.Before(period.To):
+				activity.Days++
+			case !day.Before(period.PrevFrom) && day.Before(period.From):
+				activity.PrevDays++
+			}
+		}
+		summary.Total += activity.Days
+		summary.PrevTotal += activity.PrevDays
+		summary.Activities = append(summary.Activities, activity)
+	}
+	sort.Slice(summary.Activities, func(i, j int) bool {
+		return summary.Activities[i].Name < summary.Activities[j].Name
+	})
+	for weekday, count := range weekdays {
+		if count > 0 && (!summary.HasBestWeekday || count > weekdays[summary.BestWeekday]) {
+			summary.BestWeekday = time.Weekday(weekday)
+			summary.HasBestWeekday = true
+		}
+	}
+	return summary
+}
+
+// streak counts consecutive user days with posts which end right before the moment.
+func streak(days map[time.Time]bool, before time.Time) (n int) {
+	for day := before.AddDate(0, 0, -1); days[day]; day = day.AddDate(0, 0, -1) {
+		n++
+	}
+	return n
+}
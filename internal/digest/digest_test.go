@@ -0,0 +1,131 @@
+package digest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSettingsDue(t *testing.T) {
+	var (
+		// 8 January 2024 is a Monday
+		weekly  = Settings{RotateHour: 0, Hour: 9, Weekday: int32(time.Monday)}
+		monthly = Settings{RotateHour: 6, Hour: 9, Weekday: Off, Monthly: true}
+		week    = Period{PrevFrom: at(time.January, 1, 0, 0).AddDate(0, 0, -7), From: at(time.January, 1, 0, 0), To: at(time.January, 8, 0, 0)}
+		month   = Period{PrevFrom: at(time.January, 1, 6, 0).AddDate(0, -1, 0), From: at(time.January, 1, 6, 0), To: at(time.February, 1, 6, 0)}
+	)
+	with := func(s Settings, weeklySent, monthlySent time.Time) Settings {
+		s.WeeklySentTS, s.MonthlySentTS = weeklySent, monthlySent
+		return s
+	}
+	tests := []struct {
+		name     string
+		settings Settings
+		now      time.Time
+		want     map[Kind]Period
+	}{
+		{"nothing enabled", Settings{Weekday: Off}, at(time.January, 8, 9, 0), map[Kind]Period{}},
+		{"weekly at its hour", with(weekly, at(time.January, 1, 9, 0), time.Time{}), at(time.January, 8, 9, 0), map[Kind]Period{Weekly: week}},
+		{"weekly not before its hour", with(weekly, at(time.January, 1, 9, 0), time.Time{}), at(time.January, 8, 8, 59), map[Kind]Period{}},
+		{"weekly once a week", with(weekly, at(time.January, 8, 9, 0), time.Time{}), at(time.January, 10, 12, 0), map[Kind]Period{}},
+		{"weekly caught up later in the week", with(weekly, at(time.January, 1, 9, 0), time.Time{}), at(time.January, 12, 12, 0), map[Kind]Period{Weekly: week}},
+		{"monthly on the first", with(monthly, time.Time{}, at(time.January, 1, 9, 0)), at(time.February, 1, 9, 0), map[Kind]Period{Monthly: month}},
+		{"monthly not before its hour", with(monthly, time.Time{}, at(time.January, 1, 9, 0)), at(time.February, 1, 8, 0), map[Kind]Period{}},
+		{"monthly once a month", with(monthly, time.Time{}, at(time.February, 1, 9, 0)), at(time.February, 20, 9, 0), map[Kind]Period{}},
+		{"both", Settings{RotateHour: 6, Hour: 9, Weekday: int32(time.Thursday), Monthly: true}, at(time.February, 1, 9, 0), map[Kind]Period{
+			Weekly:  {PrevFrom: at(time.January, 18, 6, 0), From: at(time.January, 25, 6, 0), To: at(time.February, 1, 6, 0)},
+			Monthly: month,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.Due(tt.now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Due(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name       string
+		rotateHour int32
+		period     Period
+		activities []string
+		posts      []Post
+		want       Summary
+	}{
+		{
+			name:       "midnight rotation",
+			rotateHour: 0,
+			period:     Period{PrevFrom: at(time.January, 1, 0, 0), From: at(time.January, 8, 0, 0), To: at(time.January, 15, 0, 0)},
+			activities: []string{"run", "read"},
+			posts: []Post{
+				{"read", at(time.January, 2, 10, 0)},
+				{"run", at(time.January, 7, 10, 0)},
+				{"run", at(time.January, 8, 10, 0)},
+				{"run", at(time.January, 8, 20, 0)},
+				{"run", at(time.January, 9, 10, 0)},
+				{"run", at(time.January, 13, 10, 0)},
+				{"run", at(time.January, 14, 10, 0)},
+			},
+			want: Summary{
+				Days: 7,
+				Activities: []Activity{
+					{Name: "read", PrevDays: 1},
+					{Name: "run", Days: 4, PrevDays: 1, StreakBefore: 1, StreakAfter: 2},
+				},
+				BestWeekday:    time.Sunday,
+				HasBestWeekday: true,
+				Total:          4,
+				PrevTotal:      2,
+			},
+		},
+		{
+			name:       "posts before the rotation count for the previous day",
+			rotateHour: 6,
+			period:     Period{PrevFrom: at(time.January, 1, 6, 0), From: at(time.January, 8, 6, 0), To: at(time.January, 15, 6, 0)},
+			activities: []string{"run", "read"},
+			posts: []Post{
+				{"run", at(time.January, 8, 5, 0)},
+				{"run", at(time.January, 9, 7, 0)},
+				{"run", at(time.January, 10, 5, 0)},
+				{"read", at(time.January, 15, 5, 0)},
+			},
+			want: Summary{
+				Days: 7,
+				Activities: []Activity{
+					{Name: "read", Days: 1, StreakAfter: 1},
+					{Name: "run", Days: 1, PrevDays: 1, StreakBefore: 1},
+				},
+				BestWeekday:    time.Sunday,
+				HasBestWeekday: true,
+				Total:          2,
+				PrevTotal:      1,
+			},
+		},
+		{
+			name:       "no posts",
+			rotateHour: 0,
+			period:     Period{PrevFrom: at(time.January, 1, 0, 0), From: at(time.February, 1, 0, 0), To: at(time.March, 1, 0, 0)},
+			activities: []string{"run"},
+			want: Summary{
+				Days:       29,
+				Activities: []Activity{{Name: "run"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Period = tt.period
+			got := Summarize(tt.rotateHour, tt.period, tt.activities, tt.posts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
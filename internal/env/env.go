@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -10,9 +11,42 @@ const (
 	TELEGRAM_TOKEN        = "TELEGRAM_TOKEN"
 	MAGIC_NUMBER          = "MAGIC_NUMBER"
 	FREEZE_HOURS          = "FREEZE_HOURS"
+	DELETE_PROMPTS        = "DELETE_PROMPTS"
+	CONVERSATION_TIMEOUT  = "CONVERSATION_TIMEOUT"
+	UNDO_WINDOW           = "UNDO_WINDOW"
+	MAX_ACTIVITIES        = "MAX_ACTIVITIES"
+	MAX_FAILURES          = "MAX_FAILURES"
+	FREEZES_PER_MONTH     = "FREEZES_PER_MONTH"
+	BATCH_CONCURRENCY     = "BATCH_CONCURRENCY"
+	BATCH_TIMEOUT         = "BATCH_TIMEOUT"
+	BATCH_USER_TIMEOUT    = "BATCH_USER_TIMEOUT"
+	STREAK_MESSAGES       = "STREAK_MESSAGES"
+	TELEGRAM_SECRET_TOKEN = "TELEGRAM_SECRET_TOKEN"
+	ADMIN_TOKEN           = "ADMIN_TOKEN"
+	METRICS_ADDR          = "METRICS_ADDR"
+	LOG_LEVEL             = "LOG_LEVEL"
+	LOG_FORMAT            = "LOG_FORMAT"
 
-	magicNumber = 347863284
-	freezeHours = 15
+	OTEL_TRACES_EXPORTER = "OTEL_TRACES_EXPORTER"
+	OTEL_SERVICE_NAME    = "OTEL_SERVICE_NAME"
+
+	magicNumber         = 347863284
+	freezeHours         = 15
+	deletePrompts       = true
+	conversationTimeout = 10
+	undoWindow          = 10
+	maxActivities       = 20
+	maxFailures         = 3
+	freezesPerMonth     = 3
+	batchConcurrency    = 8
+	batchTimeout        = 50
+	batchUserTimeout    = 15
+	streakMessages      = true
+	metricsAddr         = ":9090"
+	logLevel            = "info"
+	logFormat           = "text"
+	tracesExporter      = "none"
+	serviceName         = "marathon_procrastination_bot"
 )
 
 func Magic() int {
@@ -34,3 +68,159 @@ func FreezeHours() int {
 		return vv
 	}
 }
+
+func DeletePrompts() bool {
+	if v, has := os.LookupEnv(DELETE_PROMPTS); !has {
+		return deletePrompts
+	} else if vv, err := strconv.ParseBool(v); err != nil {
+		return deletePrompts
+	} else {
+		return vv
+	}
+}
+
+func ConversationTimeoutMinutes() int {
+	if v, has := os.LookupEnv(CONVERSATION_TIMEOUT); !has {
+		return conversationTimeout
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return conversationTimeout
+	} else {
+		return vv
+	}
+}
+
+func UndoWindowMinutes() int {
+	if v, has := os.LookupEnv(UNDO_WINDOW); !has {
+		return undoWindow
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return undoWindow
+	} else {
+		return vv
+	}
+}
+
+func MaxActivities() int {
+	if v, has := os.LookupEnv(MAX_ACTIVITIES); !has {
+		return maxActivities
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return maxActivities
+	} else {
+		return vv
+	}
+}
+
+func MaxFailures() int {
+	if v, has := os.LookupEnv(MAX_FAILURES); !has {
+		return maxFailures
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return maxFailures
+	} else {
+		return vv
+	}
+}
+
+// FreezesPerMonth is how many days a user may skip with frozen streaks in a calendar month.
+func FreezesPerMonth() int {
+	if v, has := os.LookupEnv(FREEZES_PER_MONTH); !has {
+		return freezesPerMonth
+	} else if vv, err := strconv.Atoi(v); err != nil || vv < 0 {
+		return freezesPerMonth
+	} else {
+		return vv
+	}
+}
+
+func BatchConcurrency() int {
+	if v, has := os.LookupEnv(BATCH_CONCURRENCY); !has {
+		return batchConcurrency
+	} else if vv, err := strconv.Atoi(v); err != nil || vv < 1 {
+		return batchConcurrency
+	} else {
+		return vv
+	}
+}
+
+func BatchTimeout() time.Duration {
+	if v, has := os.LookupEnv(BATCH_TIMEOUT); !has {
+		return batchTimeout * time.Second
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return batchTimeout * time.Second
+	} else {
+		return time.Duration(vv) * time.Second
+	}
+}
+
+func BatchUserTimeout() time.Duration {
+	if v, has := os.LookupEnv(BATCH_USER_TIMEOUT); !has {
+		return batchUserTimeout * time.Second
+	} else if vv, err := strconv.Atoi(v); err != nil {
+		return batchUserTimeout * time.Second
+	} else {
+		return time.Duration(vv) * time.Second
+	}
+}
+
+func StreakMessages() bool {
+	if v, has := os.LookupEnv(STREAK_MESSAGES); !has {
+		return streakMessages
+	} else if vv, err := strconv.ParseBool(v); err != nil {
+		return streakMessages
+	} else {
+		return vv
+	}
+}
+
+// TelegramSecretToken is the secret_token of the webhook, which Telegram sends with every update.
+func TelegramSecretToken() string {
+	return os.Getenv(TELEGRAM_SECRET_TOKEN)
+}
+
+// AdminToken is the key of admin requests. Admin requests are rejected when it is empty.
+func AdminToken() string {
+	return os.Getenv(ADMIN_TOKEN)
+}
+
+// MetricsAddr is the address of the metrics endpoint of the service. Empty disables it.
+func MetricsAddr() string {
+	if v, has := os.LookupEnv(METRICS_ADDR); !has {
+		return metricsAddr
+	} else {
+		return v
+	}
+}
+
+// LogLevel is the minimal level of logged messages: debug, info, warn or error.
+func LogLevel() string {
+	if v, has := os.LookupEnv(LOG_LEVEL); !has {
+		return logLevel
+	} else {
+		return v
+	}
+}
+
+// LogFormat is the format of log lines: text or json.
+func LogFormat() string {
+	if v, has := os.LookupEnv(LOG_FORMAT); !has {
+		return logFormat
+	} else {
+		return v
+	}
+}
+
+// TracesExporter is where spans are exported: otlp, console (stdout) or none.
+func TracesExporter() string {
+	if v, has := os.LookupEnv(OTEL_TRACES_EXPORTER); !has {
+		return tracesExporter
+	} else {
+		return v
+	}
+}
+
+// ServiceName is the service.name of exported spans.
+func ServiceName() string {
+	if v, has := os.LookupEnv(OTEL_SERVICE_NAME); !has {
+		return serviceName
+	} else {
+		return v
+	}
+}
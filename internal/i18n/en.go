@@ -0,0 +1,221 @@
+package i18n
+
+var en = map[Key]string{
+	Welcome: `
+
+⚡️⚡️⚡️Marathon rules ⚡️⚡️⚡️
+
+1. Start a 🎯🎯🎯New marathon🎯🎯🎯 with /post
+It is a habit or a duty you want to train.
+
+2. Every day do what you planned
+and report it to the bot.
+
+3. The bot counts consecutive days - this is your achievement 💪 (like sobriety chips).
+
+4. If you skip a day, the counter of consecutive days drops to ZERO.
+
+So don't skip 🤬
+
+⭐️⭐️⭐️Good luck, my friend! ⭐️⭐️⭐️
+`,
+	Cancelled: "OK, cancelled",
+	Saved:     "✅ Saved",
+	On:        "On",
+	Off:       "Off",
+	Enabled:   "enabled",
+	Disabled:  "disabled",
+
+	Days:            "day|days",
+	DaysGenitive:    "day|days",
+	Hours:           "hour|hours",
+	HoursGenitive:   "hour|hours",
+	Minutes:         "minute|minutes",
+	MinutesGenitive: "minute|minutes",
+
+	UserNotFound:    "you don't take part in marathons yet, use /start",
+	NothingToUndo:   "nothing to undo",
+	NoFreezesLeft:   "no freezes left this month (there are %d a month), new ones come with the next month",
+	UnexpectedError: "something went wrong, we are looking into it (error code %s)",
+
+	UserAddFailed:    "Failed to save user @%s: %v",
+	UserAdded:        "OK, @%s now takes part in our marathon",
+	UserRemoveFailed: "Failed to remove user @%s: %v",
+	UserRemoved: "OK, @%s no longer takes part in marathons\n" +
+		"Use /start to take part in marathons",
+
+	ActivitiesFailed: "Failed to get marathons of user @%s: %v",
+	ActivitiesListFailed: "Failed to get the list of marathons of user @%s: %v\n" +
+		"Use /start to take part in marathons",
+	ActivityNotFound:       "marathon not found",
+	ActivityExists:         "a marathon with this name already exists",
+	ActivityDuplicate:      "marathon %q already exists, record it with /post",
+	ActivityArchivedExists: "marathon %q already exists in the archive, bring it back with /restore",
+	TooManyActivities:      "you can't have more than %d active marathons, archive unneeded ones with /archive",
+	NameEmpty:              "the marathon name can't be empty",
+	NameTooLong:            "the marathon name can't be longer than %d characters",
+	NameCommand:            "the marathon name can't start with /",
+	NameCharset:            "the marathon name contains invalid characters",
+	NoActivities:           "No suitable marathons",
+	NewActivity:            "New marathon",
+
+	StatsTitle:  "Marathon stats of user @%s:",
+	StatsLine:   "\n- %q (days in a row: %d, last day: %d)",
+	StatsTarget: " 🎯 %d/%d",
+
+	RotateFailed: "Failed to update stats of user @%s: %v",
+	Rotated: "Stats of user @%s updated.\n" +
+		"A new day has started - don't forget your marathons!\n" +
+		"Use /post to record a marathon",
+
+	StreaksTitle:   "Day results:",
+	StreakExtended: "\n🔥 %q - %d %s in a row",
+	StreakBroken:   "\n💔 %q - the streak of %d %s is broken",
+
+	ChooseRotateHour: "Choose the hour of the daily stats rotation (UTC)",
+	RotateHourFailed: "Failed to set the hour of the daily stats rotation: %v",
+	RotateHourSet:    "Daily stats rotation of user @%s is set to %d:00 UTC",
+	HourSetToast:     "✅ %d:00 UTC",
+	InvalidHour: "Invalid parameter %q.\n" +
+		"The parameter of %s must be a number from 0 to 23",
+
+	ChooseRemindHour: "Choose the hour of the daily marathon reminder (UTC)",
+	RemindHourFailed: "Failed to set the reminder hour: %v",
+	RemindHourOff:    "Daily reminder of user @%s is off",
+	RemindHourSet:    "Daily reminder of user @%s is set to %d:00 UTC",
+
+	ChooseFirmRemind: "How many hours before the stats rotation should I firmly remind about pending marathons?",
+	FirmRemindFailed: "Failed to set the firm reminder: %v",
+	FirmRemindOff:    "Firm reminder of user @%s is off",
+	FirmRemindSet:    "Firm reminder of user @%s comes %d %s before the stats rotation",
+
+	ChooseLastCall: "Remind at the last moment (%d %s before the stats rotation)?",
+	LastCallFailed: "Failed to set the last call reminder: %v",
+	LastCallSet:    "Last call reminder of user @%s is %s",
+
+	PostPrompt: "Record a marathon",
+	PostPromptStats: "Record a marathon\n" +
+		"Use /stats to see marathon stats",
+	PostFailed:  "Failed to record marathon %q: %v",
+	PostedToast: "✅ %q +1",
+	Posted:      "✅ Marathon %q recorded",
+
+	UndoPostButton:  "↩️ Undo the last record",
+	UndoPostFailed:  "Failed to undo the record: %v",
+	UndoPostNothing: "Nothing to undo: a record can be undone within %d %s",
+	PostUndoneToast: "↩️ %q -1",
+	PostUndone:      "↩️ The last record of marathon %q is undone",
+	PostUndonePrompt: "↩️ The record of marathon %q is undone\n" +
+		"Record a marathon",
+	RemovePrompt: "I don't want to take part in the marathon anymore",
+	RemoveFailed: "Failed to remove marathon %q: %v",
+	RemovedToast: "🗑 Marathon %q removed",
+	Removed: "OK, @%s no longer takes part in marathon %q\n" +
+		"The removal can be undone within %d %s\n" +
+		"Use /archive to hide a marathon and keep its history",
+	UndoRemoveButton:  "↩️ Undo removal",
+	UndoRemoveFailed:  "Failed to undo removal of marathon %q: %v",
+	RemoveUndoneToast: "↩️ Marathon %q restored",
+	RemoveUndone:      "OK, marathon %q is restored with its streak",
+
+	ChooseRename:  "Which marathon to rename?",
+	ChooseTarget:  "Which marathon to set a target for?",
+	ChooseArchive: "Which marathon to archive?",
+	ChooseRestore: "Which marathon to restore from the archive?",
+	ArchiveFailed: "Failed to change marathon %q: %v",
+	ArchivedToast: "🗄 %q archived",
+	Archived: "OK, marathon %q is archived. Its history is kept\n" +
+		"Use /restore to bring the marathon back",
+	RestoredToast: "📤 %q restored",
+	Restored: "OK, marathon %q is active again\n" +
+		"Use /post to record a marathon",
+
+	GentleReminder: "A gentle reminder about your marathons:\n%s\n\n" +
+		"Tap a marathon to record it",
+	FirmReminder: "Less than %d %s left before the stats rotation, and the marathons are still pending:\n%s\n\n" +
+		"Don't break the streak! Tap a marathon to record it",
+	LastCallReminder: "Last chance! The stats are reset in %d %s:\n%s\n\n" +
+		"Tap a marathon to record it",
+	PendingLine:     "\n- %q (days in a row: %d 💪)",
+	AllDone:         "%s\n\nThat's all for today 🎉",
+	StillPending:    "%s\n\nStill pending:%s",
+	PauseButton:     "⏸ Pause",
+	Snooze15mButton: "⏰ 15 min",
+	Snooze1hButton:  "⏰ 1 h",
+	Snooze3hButton:  "⏰ 3 h",
+	SnoozeAtButton:  "⏰ Until…",
+	SkipButton:      "🧊 Skip the day",
+
+	PauseFailed:   "Failed to pause marathon %q: %v",
+	PausedToast:   "⏸ %q paused",
+	Paused:        "⏸ Marathon %q is paused. Record it with /post to continue",
+	SkipFailed:    "Failed to freeze marathons: %v",
+	SkippedToast:  "🧊 Day skipped",
+	Skipped:       "🧊 Today is skipped, marathon streaks are frozen until the next stats rotation. Freezes left this month: %d",
+	InvalidSnooze: "Invalid parameter %q of /snooze",
+	SnoozeFailed:  "Failed to snooze the reminder: %v",
+	SnoozedToast:  "⏰ Reminder snoozed",
+	SnoozedUntil:  "⏰ I'll remind you at %s UTC",
+
+	ChooseDigest:     "Choose the day of the weekly marathon digest or enable the monthly digest",
+	ChooseDigestHour: "Choose the hour of the weekly digest (UTC)",
+	InvalidWeekday: "Invalid parameter %q.\n" +
+		"The parameter of /digest_weekly must be a number from 0 to 6",
+	InvalidDigestHour: "Invalid parameter %q.\n" +
+		"The digest hour must be a number from 0 to 23",
+	WeeklyDigestFailed:  "Failed to set up the weekly digest: %v",
+	WeeklyDigestOff:     "Weekly digest is off",
+	WeeklyDigestSet:     "Weekly digest comes on %s, %d:00 UTC",
+	MonthlyDigestFailed: "Failed to set up the monthly digest: %v",
+	MonthlyDigestOff:    "Monthly digest is off",
+	MonthlyDigestOn:     "Monthly digest comes on the first day of every month",
+	WeeklyOffButton:     "Turn off weekly",
+	MonthlyOnButton:     "Monthly: on",
+	MonthlyOffButton:    "Monthly: off",
+	WeeklyTitle:         "Week summary",
+	MonthlyTitle:        "Month summary",
+	PrevWeek:            "previous week",
+	PrevMonth:           "previous month",
+	DigestTitle:         "📊 %s (%s - %s):\n",
+	DigestLine:          "\n- %q: %d of %d %s (%s: %d), streak: %d → %d",
+	DigestBestWeekday:   "\n\nBest day of the week: %s",
+	DigestTotal:         "\nTotal records: %d (%s: %d, %+d)",
+
+	EnterActivityName:   "Send the name of the marathon",
+	EnterActivityRename: "Send the new name of marathon %q",
+	EnterActivityTarget: "Send the target of marathon %q - how many days in a row you want to hold on (0 - no target)",
+	EnterActivitiesList: "Send a list of marathons, one per line",
+	CancelHint:          "\n\nUse /cancel to cancel",
+	ConversationExpired: "The answer timed out, start over",
+	ConversationAborted: "Can't go on: %v. Start over",
+	CreateFailed:        "Failed to save marathon %q of user @%s: %v",
+	Created: "OK, @%s now takes part in marathon %q\n" +
+		"Use /post to record a marathon",
+	RenameFailed:  "Failed to rename marathon %q of user @%s: %v",
+	Renamed:       "OK, marathon %q is now called %q",
+	InvalidTarget: "The target must be a non-negative integer, try again",
+	TargetFailed:  "Failed to set the target of marathon %q of user @%s: %v",
+	TargetReset:   "OK, the target of marathon %q is reset",
+	TargetSet:     "OK, the target of marathon %q is %d %s in a row",
+	ImportTitle:   "Marathons import:",
+
+	ChooseLanguage: "Choose the language of messages",
+	LanguageFailed: "Failed to save the language: %v",
+	LanguageSet:    "OK, now I speak English",
+
+	Weekdays[0]: "Sunday",
+	Weekdays[1]: "Monday",
+	Weekdays[2]: "Tuesday",
+	Weekdays[3]: "Wednesday",
+	Weekdays[4]: "Thursday",
+	Weekdays[5]: "Friday",
+	Weekdays[6]: "Saturday",
+
+	ShortWeekdays[0]: "Su",
+	ShortWeekdays[1]: "Mo",
+	ShortWeekdays[2]: "Tu",
+	ShortWeekdays[3]: "We",
+	ShortWeekdays[4]: "Th",
+	ShortWeekdays[5]: "Fr",
+	ShortWeekdays[6]: "Sa",
+}
@@ -0,0 +1,97 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang is a language of user-facing messages.
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+
+	// Default is used when the language of the user is unknown or not supported.
+	Default = Russian
+)
+
+// Langs are the supported languages in the order of the /language keyboard.
+var Langs = []Lang{Russian, English}
+
+// Names are the names of the languages in the languages themselves.
+var Names = map[Lang]string{
+	Russian: "Русский",
+	English: "English",
+}
+
+// Key identifies a message in catalogs.
+type Key string
+
+var catalogs = map[Lang]map[Key]string{
+	Russian: ru,
+	English: en,
+}
+
+// Parse returns the supported language of the IETF language tag,
+// e.g. the language_code of a Telegram user.
+func Parse(tag string) (Lang, bool) {
+	tag = strings.ToLower(tag)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, has := catalogs[Lang(tag)]; has {
+		return Lang(tag), true
+	}
+	return Default, false
+}
+
+// T formats the message of the key in the language. Missing messages
+// fall back to the default language and then to the key itself.
+func T(lang Lang, key Key, args ...any) string {
+	format, has := catalogs[lang][key]
+	if !has {
+		format, has = catalogs[Default][key]
+	}
+	if !has {
+		return string(key)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// N returns the plural form of the key which agrees with n.
+// Plural forms are separated by "|" in catalogs: one|few|many for Russian
+// and one|other for English.
+func N(lang Lang, key Key, n int) string {
+	forms := strings.Split(T(lang, key), "|")
+	i := plural(lang, n)
+	if i >= len(forms) {
+		i = len(forms) - 1
+	}
+	return forms[i]
+}
+
+func plural(lang Lang, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case Russian:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
@@ -0,0 +1,185 @@
+package i18n
+
+const (
+	Welcome   Key = "welcome"
+	Cancelled Key = "cancelled"
+	Saved     Key = "saved"
+	On        Key = "on"
+	Off       Key = "off"
+	Enabled   Key = "enabled"
+	Disabled  Key = "disabled"
+
+	// plurals
+	Days            Key = "days"
+	DaysGenitive    Key = "days.genitive"
+	Hours           Key = "hours"
+	HoursGenitive   Key = "hours.genitive"
+	Minutes         Key = "minutes"
+	MinutesGenitive Key = "minutes.genitive"
+
+	// errors
+	UserNotFound    Key = "error.user_not_found"
+	NothingToUndo   Key = "error.nothing_to_undo"
+	NoFreezesLeft   Key = "error.no_freezes_left"
+	UnexpectedError Key = "error.unexpected"
+
+	UserAddFailed    Key = "user.add.failed"
+	UserAdded        Key = "user.added"
+	UserRemoveFailed Key = "user.remove.failed"
+	UserRemoved      Key = "user.removed"
+
+	ActivitiesFailed       Key = "activities.failed"
+	ActivitiesListFailed   Key = "activities.list.failed"
+	ActivityNotFound       Key = "activity.not_found"
+	ActivityExists         Key = "activity.exists"
+	ActivityDuplicate      Key = "activity.duplicate"
+	ActivityArchivedExists Key = "activity.duplicate.archived"
+	TooManyActivities      Key = "activity.too_many"
+	NameEmpty              Key = "activity.name.empty"
+	NameTooLong            Key = "activity.name.too_long"
+	NameCommand            Key = "activity.name.command"
+	NameCharset            Key = "activity.name.charset"
+	NoActivities           Key = "activities.none"
+	NewActivity            Key = "activity.new"
+
+	StatsTitle  Key = "stats.title"
+	StatsLine   Key = "stats.line"
+	StatsTarget Key = "stats.target"
+
+	RotateFailed Key = "rotate.failed"
+	Rotated      Key = "rotate.done"
+
+	StreaksTitle   Key = "streaks.title"
+	StreakExtended Key = "streaks.extended"
+	StreakBroken   Key = "streaks.broken"
+
+	ChooseRotateHour Key = "rotate_hour.choose"
+	RotateHourFailed Key = "rotate_hour.failed"
+	RotateHourSet    Key = "rotate_hour.set"
+	HourSetToast     Key = "hour.set.toast"
+	InvalidHour      Key = "hour.invalid"
+
+	ChooseRemindHour Key = "remind_hour.choose"
+	RemindHourFailed Key = "remind_hour.failed"
+	RemindHourOff    Key = "remind_hour.off"
+	RemindHourSet    Key = "remind_hour.set"
+
+	ChooseFirmRemind Key = "firm_remind.choose"
+	FirmRemindFailed Key = "firm_remind.failed"
+	FirmRemindOff    Key = "firm_remind.off"
+	FirmRemindSet    Key = "firm_remind.set"
+
+	ChooseLastCall Key = "last_call.choose"
+	LastCallFailed Key = "last_call.failed"
+	LastCallSet    Key = "last_call.set"
+
+	PostPrompt      Key = "post.prompt"
+	PostPromptStats Key = "post.prompt.stats"
+	PostFailed      Key = "post.failed"
+	PostedToast     Key = "post.toast"
+	Posted          Key = "post.done"
+
+	UndoPostButton    Key = "undo_post.button"
+	UndoPostFailed    Key = "undo_post.failed"
+	UndoPostNothing   Key = "undo_post.nothing"
+	PostUndoneToast   Key = "undo_post.toast"
+	PostUndone        Key = "undo_post.done"
+	PostUndonePrompt  Key = "undo_post.done.prompt"
+	RemovePrompt      Key = "remove.prompt"
+	RemoveFailed      Key = "remove.failed"
+	RemovedToast      Key = "remove.toast"
+	Removed           Key = "remove.done"
+	UndoRemoveButton  Key = "undo_remove.button"
+	UndoRemoveFailed  Key = "undo_remove.failed"
+	RemoveUndoneToast Key = "undo_remove.toast"
+	RemoveUndone      Key = "undo_remove.done"
+
+	ChooseRename  Key = "rename.choose"
+	ChooseTarget  Key = "target.choose"
+	ChooseArchive Key = "archive.choose"
+	ChooseRestore Key = "restore.choose"
+	ArchiveFailed Key = "archive.failed"
+	ArchivedToast Key = "archive.toast"
+	Archived      Key = "archive.done"
+	RestoredToast Key = "restore.toast"
+	Restored      Key = "restore.done"
+
+	GentleReminder   Key = "reminder.gentle"
+	FirmReminder     Key = "reminder.firm"
+	LastCallReminder Key = "reminder.last_call"
+	PendingLine      Key = "reminder.pending"
+	AllDone          Key = "reminder.all_done"
+	StillPending     Key = "reminder.still_pending"
+	PauseButton      Key = "reminder.pause"
+	Snooze15mButton  Key = "reminder.snooze.15m"
+	Snooze1hButton   Key = "reminder.snooze.1h"
+	Snooze3hButton   Key = "reminder.snooze.3h"
+	SnoozeAtButton   Key = "reminder.snooze.at"
+	SkipButton       Key = "reminder.skip"
+
+	PauseFailed   Key = "pause.failed"
+	PausedToast   Key = "pause.toast"
+	Paused        Key = "pause.done"
+	SkipFailed    Key = "skip.failed"
+	SkippedToast  Key = "skip.toast"
+	Skipped       Key = "skip.done"
+	InvalidSnooze Key = "snooze.invalid"
+	SnoozeFailed  Key = "snooze.failed"
+	SnoozedToast  Key = "snooze.toast"
+	SnoozedUntil  Key = "snooze.done"
+
+	ChooseDigest        Key = "digest.choose"
+	ChooseDigestHour    Key = "digest.hour.choose"
+	InvalidWeekday      Key = "digest.weekday.invalid"
+	InvalidDigestHour   Key = "digest.hour.invalid"
+	WeeklyDigestFailed  Key = "digest.weekly.failed"
+	WeeklyDigestOff     Key = "digest.weekly.off"
+	WeeklyDigestSet     Key = "digest.weekly.set"
+	MonthlyDigestFailed Key = "digest.monthly.failed"
+	MonthlyDigestOff    Key = "digest.monthly.off"
+	MonthlyDigestOn     Key = "digest.monthly.on"
+	WeeklyOffButton     Key = "digest.weekly.off.button"
+	MonthlyOnButton     Key = "digest.monthly.on.button"
+	MonthlyOffButton    Key = "digest.monthly.off.button"
+	WeeklyTitle         Key = "digest.weekly.title"
+	MonthlyTitle        Key = "digest.monthly.title"
+	PrevWeek            Key = "digest.weekly.prev"
+	PrevMonth           Key = "digest.monthly.prev"
+	DigestTitle         Key = "digest.title"
+	DigestLine          Key = "digest.line"
+	DigestBestWeekday   Key = "digest.best_weekday"
+	DigestTotal         Key = "digest.total"
+
+	EnterActivityName   Key = "conversation.create"
+	EnterActivityRename Key = "conversation.rename"
+	EnterActivityTarget Key = "conversation.target"
+	EnterActivitiesList Key = "conversation.import"
+	CancelHint          Key = "conversation.cancel"
+	ConversationExpired Key = "conversation.expired"
+	ConversationAborted Key = "conversation.aborted"
+	CreateFailed        Key = "create.failed"
+	Created             Key = "create.done"
+	RenameFailed        Key = "rename.failed"
+	Renamed             Key = "rename.done"
+	InvalidTarget       Key = "target.invalid"
+	TargetFailed        Key = "target.failed"
+	TargetReset         Key = "target.reset"
+	TargetSet           Key = "target.set"
+	ImportTitle         Key = "import.title"
+
+	ChooseLanguage Key = "language.choose"
+	LanguageFailed Key = "language.failed"
+	LanguageSet    Key = "language.set"
+)
+
+// Weekdays and ShortWeekdays are indexed by time.Weekday.
+var (
+	Weekdays = [7]Key{
+		"weekday.sunday", "weekday.monday", "weekday.tuesday", "weekday.wednesday",
+		"weekday.thursday", "weekday.friday", "weekday.saturday",
+	}
+	ShortWeekdays = [7]Key{
+		"weekday.short.sunday", "weekday.short.monday", "weekday.short.tuesday", "weekday.short.wednesday",
+		"weekday.short.thursday", "weekday.short.friday", "weekday.short.saturday",
+	}
+)
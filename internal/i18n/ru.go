@@ -0,0 +1,221 @@
+package i18n
+
+var ru = map[Key]string{
+	Welcome: `
+
+⚡️⚡️⚡️Правила марафона ⚡️⚡️⚡️
+
+1. Заводишь себе 🎯🎯🎯Новый марафон🎯🎯🎯 через /post
+Это типа привычку или обязанность, которую хочешь тренить.
+
+2. и каждый день выполняешь то, что задумал.
+При этом отписываешься в ботике, что выполнил.
+
+3. Ботик считает непрерывное количество дней - это твоя ачивка 💪 (как у зависимых медальки 'дней в завязке').
+
+4. Если пропускаешь день - счетчик непрерывного количества дней сбрасывается в НОЛЬ.
+
+Так что не пропускай 🤬
+
+⭐️⭐️⭐️Удачи тебе, друг мой! ⭐️⭐️⭐️
+`,
+	Cancelled: "Ок, отменено",
+	Saved:     "✅ Сохранено",
+	On:        "Вкл",
+	Off:       "Выкл",
+	Enabled:   "включено",
+	Disabled:  "выключено",
+
+	Days:            "день|дня|дней",
+	DaysGenitive:    "дня|дней|дней",
+	Hours:           "час|часа|часов",
+	HoursGenitive:   "часа|часов|часов",
+	Minutes:         "минуту|минуты|минут",
+	MinutesGenitive: "минуты|минут|минут",
+
+	UserNotFound:    "ты ещё не участвуешь в марафонах, используй команду /start",
+	NothingToUndo:   "нечего отменять",
+	NoFreezesLeft:   "в этом месяце заморозки закончились (их %d в месяц), новые появятся с началом следующего месяца",
+	UnexpectedError: "что-то пошло не так, мы уже разбираемся (код ошибки %s)",
+
+	UserAddFailed:    "Не удалось сохранить пользователя @%s: %v",
+	UserAdded:        "Ок, теперь в нашем марафоне участвует @%s",
+	UserRemoveFailed: "Не удалось удалить пользователя @%s: %v",
+	UserRemoved: "Ок, теперь @%s не участвует в марафонах\n" +
+		"Используй команду /start - чтобы участвовать в марафонах",
+
+	ActivitiesFailed: "Не удалось получить марафоны пользователя @%s: %v",
+	ActivitiesListFailed: "Не удалось получить список марафонов пользователя @%s: %v\n" +
+		"Используй команду /start - чтобы участвовать в марафонах",
+	ActivityNotFound:       "марафон не найден",
+	ActivityExists:         "марафон с таким названием уже есть",
+	ActivityDuplicate:      "марафон %q уже есть, записать участие в нём можно командой /post",
+	ActivityArchivedExists: "марафон %q уже есть в архиве, вернуть его можно командой /restore",
+	TooManyActivities:      "активных марафонов не может быть больше %d, отправь ненужные в архив командой /archive",
+	NameEmpty:              "название марафона не может быть пустым",
+	NameTooLong:            "название марафона не может быть длиннее %d символов",
+	NameCommand:            "название марафона не может начинаться с /",
+	NameCharset:            "название марафона содержит недопустимые символы",
+	NoActivities:           "Нет подходящих марафонов",
+	NewActivity:            "Новый марафон",
+
+	StatsTitle:  "Статистика марафонов пользователя @%s:",
+	StatsLine:   "\n- %q (дней непрерывно: %d, за последние сутки: %d)",
+	StatsTarget: " 🎯 %d/%d",
+
+	RotateFailed: "Не удалось обновить статистику пользователя @%s: %v",
+	Rotated: "Статистика пользователя @%s обновлена.\n" +
+		"Cтартовал новый день - не забывай про свои марафоны!\n" +
+		"Используй команду /post - чтобы записать участие в марафоне",
+
+	StreaksTitle:   "Итоги дня:",
+	StreakExtended: "\n🔥 %q - %d %s подряд",
+	StreakBroken:   "\n💔 %q - серия из %d %s прервалась",
+
+	ChooseRotateHour: "Выбери час ежедневной ротации статистики (UTC)",
+	RotateHourFailed: "Не удалось установить время ежедневной ротации статистики: %v",
+	RotateHourSet:    "Время ежедневной ротации статистики пользователя @%s установлено в %d:00 UTC",
+	HourSetToast:     "✅ %d:00 UTC",
+	InvalidHour: "Недопустимое значение параметра %q.\n" +
+		"Параметр команды %s должен быть числом от 0 до 23",
+
+	ChooseRemindHour: "Выбери час ежедневного напоминания о марафонах (UTC)",
+	RemindHourFailed: "Не удалось установить время напоминания: %v",
+	RemindHourOff:    "Ежедневное напоминание пользователя @%s выключено",
+	RemindHourSet:    "Время ежедневного напоминания пользователя @%s установлено в %d:00 UTC",
+
+	ChooseFirmRemind: "За сколько часов до ротации статистики настойчиво напомнить о невыполненных марафонах?",
+	FirmRemindFailed: "Не удалось установить настойчивое напоминание: %v",
+	FirmRemindOff:    "Настойчивое напоминание пользователя @%s выключено",
+	FirmRemindSet:    "Настойчивое напоминание пользователя @%s придёт за %d %s до ротации статистики",
+
+	ChooseLastCall: "Напоминать в последний момент (за %d %s до ротации статистики)?",
+	LastCallFailed: "Не удалось настроить последнее напоминание: %v",
+	LastCallSet:    "Последнее напоминание пользователя @%s %s",
+
+	PostPrompt: "Записать участие в марафоне",
+	PostPromptStats: "Записать участие в марафоне\n" +
+		"Используй команду /stats - чтобы посмотреть статистику марафонов",
+	PostFailed:  "Не удалось сохранить участие в марафоне %q: %v",
+	PostedToast: "✅ %q +1",
+	Posted:      "✅ Участие в марафоне %q записано",
+
+	UndoPostButton:  "↩️ Отменить последнюю запись",
+	UndoPostFailed:  "Не удалось отменить запись участия: %v",
+	UndoPostNothing: "Нечего отменять: запись участия можно отменить в течение %d %s",
+	PostUndoneToast: "↩️ %q -1",
+	PostUndone:      "↩️ Последняя запись участия в марафоне %q отменена",
+	PostUndonePrompt: "↩️ Запись участия в марафоне %q отменена\n" +
+		"Записать участие в марафоне",
+	RemovePrompt: "Больше не хочу участвовать в марафоне",
+	RemoveFailed: "Не удалось удалить марафон %q: %v",
+	RemovedToast: "🗑 Марафон %q удалён",
+	Removed: "Ок, теперь @%s больше не участвует в марафоне %q\n" +
+		"Удаление можно отменить ещё %d %s\n" +
+		"Используй команду /archive - чтобы скрыть марафон, сохранив историю",
+	UndoRemoveButton:  "↩️ Отменить удаление",
+	UndoRemoveFailed:  "Не удалось отменить удаление марафона %q: %v",
+	RemoveUndoneToast: "↩️ Марафон %q восстановлен",
+	RemoveUndone:      "Ок, марафон %q восстановлен вместе с серией",
+
+	ChooseRename:  "Какой марафон переименовать?",
+	ChooseTarget:  "Для какого марафона установить цель?",
+	ChooseArchive: "Какой марафон отправить в архив?",
+	ChooseRestore: "Какой марафон вернуть из архива?",
+	ArchiveFailed: "Не удалось изменить марафон %q: %v",
+	ArchivedToast: "🗄 %q в архиве",
+	Archived: "Ок, марафон %q в архиве. История сохранена\n" +
+		"Используй команду /restore - чтобы вернуть марафон",
+	RestoredToast: "📤 %q восстановлен",
+	Restored: "Ок, марафон %q снова активен\n" +
+		"Используй команду /post - чтобы записать участие в марафоне",
+
+	GentleReminder: "Нежно напоминаю тебе про твои марафоны:\n%s\n\n" +
+		"Жми на марафон, чтобы записать участие",
+	FirmReminder: "До ротации статистики осталось меньше %d %s, а марафоны ещё не выполнены:\n%s\n\n" +
+		"Не сбрасывай серию! Жми на марафон, чтобы записать участие",
+	LastCallReminder: "Последний шанс! Через %d %s статистика обнулится:\n%s\n\n" +
+		"Жми на марафон, чтобы записать участие",
+	PendingLine:     "\n- %q (дней непрерывно: %d 💪)",
+	AllDone:         "%s\n\nНа сегодня всё 🎉",
+	StillPending:    "%s\n\nЕщё не выполнены:%s",
+	PauseButton:     "⏸ Пауза",
+	Snooze15mButton: "⏰ 15 мин",
+	Snooze1hButton:  "⏰ 1 ч",
+	Snooze3hButton:  "⏰ 3 ч",
+	SnoozeAtButton:  "⏰ До…",
+	SkipButton:      "🧊 Пропустить день",
+
+	PauseFailed:   "Не удалось поставить на паузу марафон %q: %v",
+	PausedToast:   "⏸ %q на паузе",
+	Paused:        "⏸ Марафон %q на паузе. Запиши участие через /post - чтобы продолжить",
+	SkipFailed:    "Не удалось заморозить марафоны: %v",
+	SkippedToast:  "🧊 День пропущен",
+	Skipped:       "🧊 Сегодняшний день пропущен, серии марафонов заморожены до следующей ротации статистики. Осталось заморозок в этом месяце: %d",
+	InvalidSnooze: "Недопустимое значение параметра %q команды /snooze",
+	SnoozeFailed:  "Не удалось отложить напоминание: %v",
+	SnoozedToast:  "⏰ Напоминание отложено",
+	SnoozedUntil:  "⏰ Напомню в %s UTC",
+
+	ChooseDigest:     "Выбери день еженедельной сводки по марафонам или включи ежемесячную сводку",
+	ChooseDigestHour: "Выбери час еженедельной сводки (UTC)",
+	InvalidWeekday: "Недопустимое значение параметра %q.\n" +
+		"Параметр команды /digest_weekly должен быть числом от 0 до 6",
+	InvalidDigestHour: "Недопустимое значение параметра %q.\n" +
+		"Час сводки должен быть числом от 0 до 23",
+	WeeklyDigestFailed:  "Не удалось настроить еженедельную сводку: %v",
+	WeeklyDigestOff:     "Еженедельная сводка выключена",
+	WeeklyDigestSet:     "Еженедельная сводка будет приходить: %s, %d:00 UTC",
+	MonthlyDigestFailed: "Не удалось настроить ежемесячную сводку: %v",
+	MonthlyDigestOff:    "Ежемесячная сводка выключена",
+	MonthlyDigestOn:     "Ежемесячная сводка будет приходить первого числа каждого месяца",
+	WeeklyOffButton:     "Выключить еженедельный",
+	MonthlyOnButton:     "Ежемесячный: вкл",
+	MonthlyOffButton:    "Ежемесячный: выкл",
+	WeeklyTitle:         "Итоги недели",
+	MonthlyTitle:        "Итоги месяца",
+	PrevWeek:            "прошлая неделя",
+	PrevMonth:           "прошлый месяц",
+	DigestTitle:         "📊 %s (%s - %s):\n",
+	DigestLine:          "\n- %q: %d из %d %s (%s: %d), серия: %d → %d",
+	DigestBestWeekday:   "\n\nЛучший день недели: %s",
+	DigestTotal:         "\nВсего отметок: %d (%s: %d, %+d)",
+
+	EnterActivityName:   "Напиши название марафона",
+	EnterActivityRename: "Напиши новое название марафона %q",
+	EnterActivityTarget: "Напиши цель марафона %q - сколько дней подряд хочешь продержаться (0 - без цели)",
+	EnterActivitiesList: "Пришли список марафонов, каждый с новой строки",
+	CancelHint:          "\n\nИспользуй команду /cancel - чтобы отменить",
+	ConversationExpired: "Время ожидания ответа истекло, начни заново",
+	ConversationAborted: "Не удалось продолжить: %v. Начни заново",
+	CreateFailed:        "Не удалось сохранить участие в марафоне %q пользователя @%s: %v",
+	Created: "Ок, теперь @%s участвует в марафоне %q\n" +
+		"Используй команду /post - чтобы записать участие в марафоне",
+	RenameFailed:  "Не удалось переименовать марафон %q пользователя @%s: %v",
+	Renamed:       "Ок, марафон %q теперь называется %q",
+	InvalidTarget: "Цель марафона должна быть целым неотрицательным числом, попробуй ещё раз",
+	TargetFailed:  "Не удалось установить цель марафона %q пользователя @%s: %v",
+	TargetReset:   "Ок, цель марафона %q сброшена",
+	TargetSet:     "Ок, цель марафона %q - %d %s подряд",
+	ImportTitle:   "Импорт марафонов:",
+
+	ChooseLanguage: "Выбери язык сообщений",
+	LanguageFailed: "Не удалось сохранить язык: %v",
+	LanguageSet:    "Ок, теперь я говорю по-русски",
+
+	Weekdays[0]: "воскресенье",
+	Weekdays[1]: "понедельник",
+	Weekdays[2]: "вторник",
+	Weekdays[3]: "среда",
+	Weekdays[4]: "четверг",
+	Weekdays[5]: "пятница",
+	Weekdays[6]: "суббота",
+
+	ShortWeekdays[0]: "Вс",
+	ShortWeekdays[1]: "Пн",
+	ShortWeekdays[2]: "Вт",
+	ShortWeekdays[3]: "Ср",
+	ShortWeekdays[4]: "Чт",
+	ShortWeekdays[5]: "Пт",
+	ShortWeekdays[6]: "Сб",
+}
@@ -0,0 +1,98 @@
+// Package logging configures structured logging and carries correlation
+// attributes of the update or the job through the context, so that every
+// log line of one update across layers can be found by them, along with
+// its trace.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"marathon_procrastination_bot/internal/env"
+)
+
+// Keys of correlation attributes.
+const (
+	UpdateID = "update_id"
+	UserID   = "user_id"
+	ChatID   = "chat_id"
+	Command  = "command"
+	Job      = "job"
+	TraceID  = "trace_id"
+	SpanID   = "span_id"
+)
+
+var setup sync.Once
+
+// Setup makes slog log by LOG_LEVEL in LOG_FORMAT with correlation attributes
+// of the context. Subsequent calls do nothing.
+func Setup() {
+	setup.Do(func() {
+		options := &slog.HandlerOptions{Level: level(env.LogLevel())}
+		var handler slog.Handler = slog.NewTextHandler(os.Stderr, options)
+		if strings.EqualFold(env.LogFormat(), "json") {
+			handler = slog.NewJSONHandler(os.Stderr, options)
+		}
+		slog.SetDefault(slog.New(contextHandler{handler}))
+	})
+}
+
+func level(s string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+type attrsKey struct{}
+
+// With returns the context which adds the attributes to every log line logged with it.
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.Record{}
+	record.Add(args...)
+	attrs := append([]slog.Attr(nil), attributes(ctx)...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attributes(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds correlation attributes of the context and its span to records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := attributes(ctx)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs[:len(attrs):len(attrs)],
+			slog.String(TraceID, span.TraceID().String()),
+			slog.String(SpanID, span.SpanID().String()),
+		)
+	}
+	if len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// LatencyBuckets are upper bounds of latencies in seconds.
+	LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// SizeBuckets are upper bounds of numbers of users in a batch.
+	SizeBuckets = []float64{0, 1, 10, 50, 100, 500, 1000, 5000, 10000}
+)
+
+var (
+	Updates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_updates_total",
+		Help: "Updates handled by the bot by command and result: ok, error, reply_failed or duplicate.",
+	}, []string{"command", "result"})
+	UpdateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bot_update_duration_seconds",
+		Help:    "Latency of handling updates by command.",
+		Buckets: LatencyBuckets,
+	}, []string{"command"})
+	StorageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bot_storage_query_duration_seconds",
+		Help:    "Latency of storage methods including retries.",
+		Buckets: LatencyBuckets,
+	}, []string{"method"})
+	StorageRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_storage_retries_total",
+		Help: "Retried attempts of storage methods.",
+	}, []string{"method"})
+	StorageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_storage_errors_total",
+		Help: "Storage methods failed after all retries.",
+	}, []string{"method"})
+	OutboxMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_outbox_messages_total",
+		Help: "Messages delivered from the outbox by result: sent, failed or blocked.",
+	}, []string{"result"})
+	BatchSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bot_batch_size",
+		Help:    "Users listed for a run of a batch job.",
+		Buckets: SizeBuckets,
+	}, []string{"job"})
+	BatchUsers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bot_batch_users_total",
+		Help: "Users handled by batch jobs by outcome: processed, failed or skipped.",
+	}, []string{"job", "outcome"})
+	ActiveUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bot_active_users",
+		Help: "Registered users who did not block the bot.",
+	})
+	ActiveMarathons = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bot_active_marathons",
+		Help: "Marathons which are neither archived nor deleted.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		Updates, UpdateDuration,
+		StorageDuration, StorageRetries, StorageErrors,
+		OutboxMessages,
+		BatchSize, BatchUsers,
+		ActiveUsers, ActiveMarathons,
+	)
+}
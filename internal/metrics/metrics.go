@@ -0,0 +1,83 @@
+// Package metrics collects metrics of the bot and exposes them to Prometheus,
+// or as a JSON snapshot where nothing scrapes the process.
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Registry is the registry of the metrics of the bot. It holds only metrics of
+// the bot, so that the snapshot is not cluttered with metrics of the runtime.
+var Registry = prometheus.NewRegistry()
+
+// Handler serves metrics to Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Sample is the value of a metric for one combination of label values.
+type Sample struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	// Value is the value of a counter or a gauge.
+	Value *float64 `json:"value,omitempty"`
+	// Count, Sum and cumulative Buckets by upper bound are values of a histogram.
+	Count   uint64            `json:"count,omitempty"`
+	Sum     float64           `json:"sum,omitempty"`
+	Buckets map[string]uint64 `json:"buckets,omitempty"`
+}
+
+// Snapshot is the state of all metrics by name.
+type Snapshot map[string][]Sample
+
+// Gather returns the current state of all metrics which have values.
+func Gather() (Snapshot, error) {
+	families, err := Registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(Snapshot, len(families))
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			sample := Sample{}
+			if len(metric.GetLabel()) > 0 {
+				sample.Labels = make(map[string]string, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					sample.Labels[label.GetName()] = label.GetValue()
+				}
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				value := metric.GetCounter().GetValue()
+				sample.Value = &value
+			case dto.MetricType_GAUGE:
+				value := metric.GetGauge().GetValue()
+				sample.Value = &value
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				sample.Count, sample.Sum = histogram.GetSampleCount(), histogram.GetSampleSum()
+				sample.Buckets = make(map[string]uint64, len(histogram.GetBucket())+1)
+				for _, bucket := range histogram.GetBucket() {
+					sample.Buckets[format(bucket.GetUpperBound())] = bucket.GetCumulativeCount()
+				}
+				sample.Buckets[format(math.Inf(1))] = sample.Count
+			default:
+				continue
+			}
+			snapshot[family.GetName()] = append(snapshot[family.GetName()], sample)
+		}
+	}
+	return snapshot, nil
+}
+
+func format(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limits of the Bot API for messages sent by a bot.
+const (
+	GlobalRate = 30
+	ChatRate   = 1
+)
+
+// Limiter is a token bucket of GlobalRate messages per second shared by all
+// chats, with at most ChatRate messages per second to a single chat.
+type Limiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	chats  map[int64]time.Time
+	paused time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		tokens: GlobalRate,
+		last:   time.Now(),
+		chats:  make(map[int64]time.Time),
+	}
+}
+
+// Wait blocks until a message to the chat may be sent.
+func (l *Limiter) Wait(ctx context.Context, chatID int64) error {
+	for {
+		delay := l.reserve(chatID, time.Now())
+		if delay == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Pause stops sending to all chats for the duration, when Telegram asks to retry later.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.paused) {
+		l.paused = until
+	}
+}
+
+// reserve takes a token and returns zero, or returns how long to wait before trying again.
+func (l *Limiter) reserve(chatID int64, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Before(l.paused) {
+		return l.paused.Sub(now)
+	}
+	l.tokens += now.Sub(l.last).Seconds() * GlobalRate
+	if l.tokens > GlobalRate {
+		l.tokens = GlobalRate
+	}
+	l.last = now
+	if next := l.chats[chatID]; now.Before(next) {
+		return next.Sub(now)
+	}
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / GlobalRate * float64(time.Second))
+	}
+	l.tokens--
+	l.chats[chatID] = now.Add(time.Second / ChatRate)
+	for id, next := range l.chats {
+		if now.After(next) {
+			delete(l.chats, id)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,207 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/logging"
+	"marathon_procrastination_bot/internal/metrics"
+)
+
+const (
+	// MaxAttempts is the number of attempts after which a message is dropped.
+	MaxAttempts = 5
+	// Backoff is the delay after the first failed attempt, doubled for every next one.
+	Backoff = 30 * time.Second
+
+	// Lease is how long claimed messages are kept from other flushes. It
+	// covers delivery of a batch within the rate limits.
+	Lease = 2 * time.Minute
+
+	batchSize = 100
+)
+
+// Message is an outgoing message persisted until it is delivered.
+// Messages to the same chat are delivered in the order of their IDs.
+type Message struct {
+	ChatID   int64
+	ID       uint64
+	UserID   int64
+	Text     string
+	Keyboard *models.InlineKeyboardMarkup
+	Attempts uint32
+}
+
+type Storage interface {
+	EnqueueMessages(ctx context.Context, messages ...Message) error
+	PendingMessages(ctx context.Context, limit int) (messages []Message, _ error)
+	// ClaimMessages leases pending messages to the owner, so that concurrent
+	// flushes in other processes do not deliver them too.
+	ClaimMessages(ctx context.Context, owner string, limit int, lease time.Duration) (messages []Message, _ error)
+	ReleaseOutboxMessages(ctx context.Context, owner string) error
+	DeleteOutboxMessage(ctx context.Context, chatID int64, id uint64) error
+	// PostponeOutboxMessages delays all messages of the chat, so that
+	// later messages do not overtake the failed one.
+	PostponeOutboxMessages(ctx context.Context, chatID int64, id uint64, attempts uint32, until time.Time) error
+}
+
+// Sender delivers the message to Telegram.
+type Sender func(ctx context.Context, message Message) error
+
+// Queue is the outbox of the bot: messages are stored first and then
+// delivered within rate limits of the Bot API.
+type Queue struct {
+	storage Storage
+	send    Sender
+	limiter *Limiter
+	// owner identifies leases of the queue among processes.
+	owner string
+	// Blocked classifies errors after which the message is dropped and the user
+	// is reported to OnBlocked instead of retrying.
+	Blocked   func(err error) bool
+	OnBlocked func(ctx context.Context, userID int64)
+}
+
+func New(s Storage, send Sender) *Queue {
+	return &Queue{
+		storage:   s,
+		send:      send,
+		limiter:   NewLimiter(),
+		owner:     newOwner(),
+		Blocked:   func(error) bool { return false },
+		OnBlocked: func(context.Context, int64) {},
+	}
+}
+
+// Enqueue stores the messages for delivery.
+func (q *Queue) Enqueue(ctx context.Context, messages ...Message) error {
+	Number(messages)
+	return q.storage.EnqueueMessages(ctx, messages...)
+}
+
+// Number assigns IDs to the messages, so that they are delivered after the
+// messages queued before and in the given order.
+func Number(messages []Message) {
+	id := uint64(time.Now().UnixNano())
+	for i := range messages {
+		messages[i].ID = id + uint64(i)
+	}
+}
+
+// Flush delivers pending messages until there are none left or the context is done.
+// A failed message postpones the rest of its chat, other chats go on.
+func (q *Queue) Flush(ctx context.Context) (sent int, _ error) {
+	for {
+		n, err := q.FlushBatch(ctx, batchSize)
+		sent += n
+		if err != nil || n == 0 {
+			return sent, err
+		}
+	}
+}
+
+// FlushBatch delivers at most limit pending messages, e.g. the reply to an
+// update right after it is handled. Messages are leased for the delivery,
+// and the ones left undelivered are released.
+func (q *Queue) FlushBatch(ctx context.Context, limit int) (sent int, _ error) {
+	messages, err := q.storage.ClaimMessages(ctx, q.owner, limit, Lease)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	defer func() {
+		if err := q.storage.ReleaseOutboxMessages(context.WithoutCancel(ctx), q.owner); err != nil {
+			slog.ErrorContext(ctx, "release outbox messages", "error", err)
+		}
+	}()
+	var (
+		errs    []error
+		stalled = make(map[int64]bool)
+	)
+	for _, message := range messages {
+		if stalled[message.ChatID] {
+			continue
+		}
+		if err := q.limiter.Wait(ctx, message.ChatID); err != nil {
+			return sent, errors.Join(append(errs, err)...)
+		}
+		err := q.deliver(ctx, message)
+		if err != nil {
+			stalled[message.ChatID] = true
+			errs = append(errs, fmt.Errorf("chat %d: %w", message.ChatID, err))
+			continue
+		}
+		sent++
+	}
+	// failed chats are postponed, the rest is picked up by the next flush
+	return sent, errors.Join(errs...)
+}
+
+// Pending counts messages due for delivery, at most one flush batch of them.
+func (q *Queue) Pending(ctx context.Context) (int, error) {
+	messages, err := q.storage.PendingMessages(ctx, batchSize)
+	return len(messages), err
+}
+
+// deliver sends the message and removes it from the outbox, or postpones it.
+func (q *Queue) deliver(ctx context.Context, message Message) error {
+	err := q.send(ctx, message)
+	switch {
+	case err == nil:
+		metrics.OutboxMessages.WithLabelValues("sent").Inc()
+		return q.storage.DeleteOutboxMessage(ctx, message.ChatID, message.ID)
+	case q.Blocked(err):
+		metrics.OutboxMessages.WithLabelValues("blocked").Inc()
+		slog.InfoContext(ctx, "message dropped, the bot is blocked",
+			logging.ChatID, message.ChatID, logging.UserID, message.UserID, "error", err)
+		q.OnBlocked(ctx, message.UserID)
+		return errors.Join(err, q.storage.DeleteOutboxMessage(ctx, message.ChatID, message.ID))
+	}
+	metrics.OutboxMessages.WithLabelValues("failed").Inc()
+	slog.WarnContext(ctx, "message not delivered",
+		logging.ChatID, message.ChatID, logging.UserID, message.UserID, "attempts", message.Attempts+1, "error", err)
+	if after, ok := RetryAfter(err); ok {
+		q.limiter.Pause(after)
+		return errors.Join(err, q.storage.PostponeOutboxMessages(ctx,
+			message.ChatID, message.ID, message.Attempts, time.Now().UTC().Add(after),
+		))
+	}
+	message.Attempts++
+	if message.Attempts >= MaxAttempts {
+		return errors.Join(err, q.storage.DeleteOutboxMessage(ctx, message.ChatID, message.ID))
+	}
+	return errors.Join(err, q.storage.PostponeOutboxMessages(ctx,
+		message.ChatID, message.ID, message.Attempts, time.Now().UTC().Add(Backoff<<(message.Attempts-1)),
+	))
+}
+
+var retryAfter = regexp.MustCompile(`"retry_after":\s*(\d+)`)
+
+// RetryAfter extracts the delay requested by Telegram in a 429 response.
+// Bot API errors carry no types, so the response is parsed from the text.
+func RetryAfter(err error) (time.Duration, bool) {
+	if err == nil || !strings.Contains(err.Error(), "statusCode 429") {
+		return 0, false
+	}
+	if m := retryAfter.FindStringSubmatch(err.Error()); m != nil {
+		if seconds, err := strconv.Atoi(m[1]); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return time.Second, true
+}
+
+func newOwner() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,80 @@
+package reminder
+
+import (
+	"time"
+)
+
+// Step is a stage of the escalating reminder policy.
+type Step int
+
+const (
+	None Step = iota
+	Gentle
+	Firm
+	LastCall
+)
+
+const (
+	// Off disables the gentle reminder at a chosen hour. The user is pinged
+	// by the legacy FREEZE_HOURS based notification instead.
+	Off = -1
+
+	DefaultFirmHours = 3
+
+	LastCallBefore = 30 * time.Minute
+)
+
+// Policy is a per-user reminder configuration. All hours are in UTC.
+type Policy struct {
+	RotateHour int32
+	GentleHour int32
+	FirmHours  int32
+	LastCall   bool
+}
+
+// State holds the moments when each step was sent last time.
+type State struct {
+	Gentle   time.Time
+	Firm     time.Time
+	LastCall time.Time
+}
+
+// DayStart returns the beginning of the user day which contains now.
+// The user day starts at the hour of stats rotation.
+func DayStart(rotateHour int32, now time.Time) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), int(rotateHour), 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.Add(-24 * time.Hour)
+	}
+	return start
+}
+
+// Due returns the most escalated step which must be sent at now.
+// Each step is sent at most once per user day, and a less escalated
+// step is never sent after a more escalated one.
+func (p Policy) Due(s State, now time.Time) Step {
+	var (
+		dayStart = DayStart(p.RotateHour, now)
+		rotation = dayStart.Add(24 * time.Hour)
+	)
+	if p.LastCall && !now.Before(rotation.Add(-LastCallBefore)) {
+		if s.LastCall.Before(dayStart) {
+			return LastCall
+		}
+		return None
+	}
+	if p.FirmHours > 0 && !now.Before(rotation.Add(-time.Duration(p.FirmHours)*time.Hour)) {
+		if s.Firm.Before(dayStart) && s.LastCall.Before(dayStart) {
+			return Firm
+		}
+		return None
+	}
+	if p.GentleHour != Off {
+		gentleAt := dayStart.Add(time.Duration((p.GentleHour-p.RotateHour+24)%24) * time.Hour)
+		if !now.Before(gentleAt) && s.Gentle.Before(dayStart) && s.Firm.Before(dayStart) && s.LastCall.Before(dayStart) {
+			return Gentle
+		}
+	}
+	return None
+}
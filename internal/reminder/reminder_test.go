@@ -0,0 +1,76 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestDayStart(t *testing.T) {
+	tests := []struct {
+		name       string
+		rotateHour int32
+		now        time.Time
+		want       time.Time
+	}{
+		{"midnight rotation", 0, at(10, 15, 0), at(10, 0, 0)},
+		{"at the rotation", 6, at(10, 6, 0), at(10, 6, 0)},
+		{"before the rotation", 6, at(10, 5, 59), at(9, 6, 0)},
+		{"after the rotation", 6, at(10, 23, 0), at(10, 6, 0)},
+		{"late rotation", 23, at(10, 1, 0), at(9, 23, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DayStart(tt.rotateHour, tt.now); !got.Equal(tt.want) {
+				t.Errorf("DayStart(%d, %v) = %v, want %v", tt.rotateHour, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyDue(t *testing.T) {
+	var (
+		defaults = Policy{RotateHour: 0, GentleHour: 9, FirmHours: DefaultFirmHours, LastCall: true}
+		// the user day of rotation at 06:00 spans midnight
+		shifted = Policy{RotateHour: 6, GentleHour: 2, FirmHours: 4, LastCall: true}
+	)
+	tests := []struct {
+		name   string
+		policy Policy
+		state  State
+		now    time.Time
+		want   Step
+	}{
+		{"nothing before the gentle hour", defaults, State{}, at(10, 8, 59), None},
+		{"gentle at its hour", defaults, State{}, at(10, 9, 0), Gentle},
+		{"gentle once a day", defaults, State{Gentle: at(10, 9, 0)}, at(10, 12, 0), None},
+		{"gentle again next day", defaults, State{Gentle: at(9, 9, 0)}, at(10, 9, 0), Gentle},
+		{"gentle off", Policy{GentleHour: Off, FirmHours: DefaultFirmHours, LastCall: true}, State{}, at(10, 12, 0), None},
+		{"firm hours before rotation", defaults, State{}, at(10, 21, 0), Firm},
+		{"firm after gentle", defaults, State{Gentle: at(10, 9, 0)}, at(10, 21, 0), Firm},
+		{"firm once a day", defaults, State{Firm: at(10, 21, 0)}, at(10, 22, 0), None},
+		{"no gentle after firm", Policy{GentleHour: 22, FirmHours: 3, LastCall: true}, State{Firm: at(10, 21, 0)}, at(10, 22, 0), None},
+		{"firm off", Policy{GentleHour: 9, FirmHours: 0, LastCall: true}, State{Gentle: at(10, 9, 0)}, at(10, 22, 0), None},
+		{"last call half an hour before rotation", defaults, State{Firm: at(10, 21, 0)}, at(10, 23, 30), LastCall},
+		{"no last call before its window", defaults, State{Firm: at(10, 21, 0)}, at(10, 23, 29), None},
+		{"last call once a day", defaults, State{LastCall: at(10, 23, 30)}, at(10, 23, 45), None},
+		{"no firm after last call", defaults, State{LastCall: at(10, 23, 30)}, at(10, 23, 45), None},
+		{"last call off falls back to firm", Policy{GentleHour: 9, FirmHours: 3, LastCall: false}, State{}, at(10, 23, 45), Firm},
+		{"gentle after midnight in the shifted day", shifted, State{}, at(11, 2, 0), Firm},
+		{"gentle hour wraps the shifted day", Policy{RotateHour: 6, GentleHour: 2, FirmHours: 0, LastCall: false}, State{}, at(11, 2, 0), Gentle},
+		{"not yet gentle in the shifted day", Policy{RotateHour: 6, GentleHour: 2, FirmHours: 0, LastCall: false}, State{}, at(10, 23, 0), None},
+		{"gentle of the previous shifted day", Policy{RotateHour: 6, GentleHour: 2, FirmHours: 0, LastCall: false}, State{Gentle: at(10, 2, 0)}, at(11, 2, 0), Gentle},
+		{"last call before the shifted rotation", shifted, State{}, at(11, 5, 30), LastCall},
+		{"new shifted day after rotation", shifted, State{LastCall: at(11, 5, 30)}, at(11, 6, 0), None},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Due(tt.state, tt.now); got != tt.want {
+				t.Errorf("Due(%+v, %v) = %d, want %d", tt.state, tt.now, got, tt.want)
+			}
+		})
+	}
+}
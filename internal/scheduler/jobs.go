@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/logging"
+	"marathon_procrastination_bot/internal/metrics"
+	"marathon_procrastination_bot/internal/outbox"
+	"marathon_procrastination_bot/internal/storage"
+	"marathon_procrastination_bot/internal/telegram"
+)
+
+type Storage interface {
+	UsersForRotate(ctx context.Context, hour int32) (ids []int64, err error)
+	RotateStats(ctx context.Context, hour int32, messages storage.StreakMessages) (rotations []storage.Rotation, _ error)
+	PurgeDeletedActivities(ctx context.Context) error
+	UsersForNotification(ctx context.Context) (ids []int64, err error)
+	UsersForReminders(ctx context.Context) (ids []int64, err error)
+	UsersWithExpiredSnooze(ctx context.Context) (ids []int64, err error)
+	UsersForDigest(ctx context.Context) (ids []int64, err error)
+	UsersWithoutActivities(ctx context.Context) (ids []int64, err error)
+	CountActive(ctx context.Context) (users uint64, marathons uint64, _ error)
+	UserFailures(ctx context.Context, job string) (failures map[int64]storage.Failure, _ error)
+	RecordUserFailure(ctx context.Context, userID int64, job string, permanent bool, reason string) error
+	ResetUserFailures(ctx context.Context, userID int64, job string) error
+}
+
+type Agent interface {
+	PingUser(ctx context.Context, userID int64) error
+	RemindUser(ctx context.Context, userID int64) error
+	WakeUser(ctx context.Context, userID int64) error
+	DigestUser(ctx context.Context, userID int64) error
+	Welcome(ctx context.Context, userID int64) error
+	StreakMessages(rotations []storage.Rotation) []outbox.Message
+}
+
+// Outbox delivers queued messages.
+type Outbox interface {
+	Flush(ctx context.Context) (sent int, _ error)
+}
+
+// Jobs are the periodic jobs of the bot. Storage lists only users for whom
+// the job is still due, so repeated runs skip users processed by failed ones.
+// Welcomes are not tracked, so they are not retried.
+func Jobs(s Storage, a Agent, o Outbox) []Job {
+	return []Job{
+		{Name: "rotate", Every: time.Hour, Retries: 3, Backoff: time.Minute, Run: Rotate(s, a).Run},
+		{Name: "notify", Every: time.Minute, Retries: 1, Backoff: 5 * time.Second, Run: Notify(s, a).Run},
+		{Name: "remind", Every: time.Minute, Retries: 1, Backoff: 5 * time.Second, Run: Remind(s, a).Run},
+		{Name: "wake", Every: time.Minute, Retries: 1, Backoff: 5 * time.Second, Run: Wake(s, a).Run},
+		{Name: "digest", Every: time.Minute, Retries: 1, Backoff: 5 * time.Second, Run: Digest(s, a).Run},
+		{Name: "welcome", Every: 24 * time.Hour, Run: Welcome(s, a).Run},
+		{Name: "outbox", Every: 5 * time.Second, Run: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, env.BatchTimeout())
+			defer cancel()
+			_, err := o.Flush(ctx)
+			return err
+		}},
+		{Name: "gauges", Every: time.Minute, Run: func(ctx context.Context) error {
+			return Gauges(ctx, s)
+		}},
+	}
+}
+
+// Gauges updates gauges of active users and marathons.
+func Gauges(ctx context.Context, s Storage) error {
+	users, marathons, err := s.CountActive(ctx)
+	if err != nil {
+		return err
+	}
+	metrics.ActiveUsers.Set(float64(users))
+	metrics.ActiveMarathons.Set(float64(marathons))
+	return nil
+}
+
+// Summary is the outcome of a batch run.
+type Summary struct {
+	Processed int       `json:"processed"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	Failures  []Failure `json:"failures,omitempty"`
+}
+
+// Failure is the failure of a batch for one user.
+type Failure struct {
+	UserID    int64  `json:"user_id"`
+	Permanent bool   `json:"permanent"`
+	Error     string `json:"error"`
+}
+
+// Batch is a job which processes users one by one. On dry run the batch only
+// counts users it would process and skip, and changes nothing.
+type Batch func(ctx context.Context, dryRun bool) (Summary, error)
+
+// Run runs the batch as a scheduler job, dropping the summary.
+func (b Batch) Run(ctx context.Context) error {
+	_, err := b(ctx, false)
+	return err
+}
+
+// Rotate rotates stats of all users whose rotation hour has come in bulk,
+// queues messages about their streaks and purges activities deleted longer
+// than the undo window ago.
+func Rotate(s Storage, a Agent) Batch {
+	return func(ctx context.Context, dryRun bool) (Summary, error) {
+		return RotateAt(s, a, int32(time.Now().UTC().Hour()))(ctx, dryRun)
+	}
+}
+
+// RotateAt is Rotate for users with the given rotation hour, e.g. to catch up on a missed hour.
+func RotateAt(s Storage, a Agent, hour int32) Batch {
+	return func(ctx context.Context, dryRun bool) (summary Summary, _ error) {
+		ctx, cancel := context.WithTimeout(logging.With(ctx, logging.Job, "rotate"), env.BatchTimeout())
+		defer cancel()
+		if dryRun {
+			ids, err := s.UsersForRotate(ctx, hour)
+			summary.Processed = len(ids)
+			return summary, err
+		}
+		rotations, err := s.RotateStats(ctx, hour, a.StreakMessages)
+		summary.Processed = len(rotations)
+		metrics.BatchSize.WithLabelValues("rotate").Observe(float64(len(rotations)))
+		metrics.BatchUsers.WithLabelValues("rotate", "processed").Add(float64(len(rotations)))
+		slog.InfoContext(ctx, "batch done", "processed", summary.Processed)
+		// messages about streaks are queued along with the rotation
+		return summary, errors.Join(err, s.PurgeDeletedActivities(ctx))
+	}
+}
+
+func Notify(s Storage, a Agent) Batch {
+	return ForEach(s, "notify", s.UsersForNotification, a.PingUser)
+}
+
+func Remind(s Storage, a Agent) Batch {
+	return ForEach(s, "remind", s.UsersForReminders, a.RemindUser)
+}
+
+func Wake(s Storage, a Agent) Batch {
+	return ForEach(s, "wake", s.UsersWithExpiredSnooze, a.WakeUser)
+}
+
+func Digest(s Storage, a Agent) Batch {
+	return ForEach(s, "digest", s.UsersForDigest, a.DigestUser)
+}
+
+func Welcome(s Storage, a Agent) Batch {
+	return ForEach(s, "welcome", s.UsersWithoutActivities, a.Welcome)
+}
+
+// ForEach applies fn to every listed user of the job by BATCH_CONCURRENCY
+// workers. A failure for one user does not abandon the rest. Failures are
+// recorded per user, and users who failed permanently MAX_FAILURES times in a
+// row are skipped until they /start again. Only transient failures fail the
+// batch, so only they are retried. The batch is limited by BATCH_TIMEOUT and
+// every user by BATCH_USER_TIMEOUT.
+func ForEach(s Storage, job string, list func(ctx context.Context) ([]int64, error), fn func(ctx context.Context, userID int64) error) Batch {
+	return func(ctx context.Context, dryRun bool) (summary Summary, _ error) {
+		ctx, cancel := context.WithTimeout(logging.With(ctx, logging.Job, job), env.BatchTimeout())
+		defer cancel()
+		ids, err := list(ctx)
+		if err != nil {
+			return summary, err
+		}
+		failures, err := s.UserFailures(ctx, job)
+		if err != nil {
+			return summary, err
+		}
+		if dryRun {
+			for _, id := range ids {
+				if skip(failures[id]) {
+					summary.Skipped++
+				} else {
+					summary.Processed++
+				}
+			}
+			return summary, nil
+		}
+		var (
+			mu   sync.Mutex
+			errs []error
+		)
+		Pool(ctx, env.BatchConcurrency(), ids, func(ctx context.Context, id int64) {
+			failure := failures[id]
+			if skip(failure) {
+				return
+			}
+			ctx = logging.With(ctx, logging.UserID, id)
+			userCtx, cancel := context.WithTimeout(ctx, env.BatchUserTimeout())
+			defer cancel()
+			err := fn(userCtx, id)
+			if err == nil {
+				if failure.Count > 0 {
+					err = s.ResetUserFailures(ctx, id, job)
+				}
+				mu.Lock()
+				summary.Processed++
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			permanent := telegram.Permanent(err)
+			slog.WarnContext(ctx, "batch failed for user", "permanent", permanent, "error", err)
+			recorded := s.RecordUserFailure(ctx, id, job, permanent, err.Error())
+			mu.Lock()
+			defer mu.Unlock()
+			summary.Failed++
+			summary.Failures = append(summary.Failures, Failure{
+				UserID:    id,
+				Permanent: permanent,
+				Error:     err.Error(),
+			})
+			errs = append(errs, recorded)
+			if !permanent {
+				errs = append(errs, fmt.Errorf("user %d: %w", id, err))
+			}
+		})
+		// users not reached before the deadline are left for the next run
+		summary.Skipped = len(ids) - summary.Processed - summary.Failed
+		slog.InfoContext(ctx, "batch done",
+			"processed", summary.Processed, "failed", summary.Failed, "skipped", summary.Skipped)
+		metrics.BatchSize.WithLabelValues(job).Observe(float64(len(ids)))
+		metrics.BatchUsers.WithLabelValues(job, "processed").Add(float64(summary.Processed))
+		metrics.BatchUsers.WithLabelValues(job, "failed").Add(float64(summary.Failed))
+		metrics.BatchUsers.WithLabelValues(job, "skipped").Add(float64(summary.Skipped))
+		return summary, errors.Join(append(errs, ctx.Err())...)
+	}
+}
+
+// skip tells whether the user failed permanently too many times in a row.
+func skip(failure storage.Failure) bool {
+	return failure.Permanent && failure.Count >= uint64(env.MaxFailures())
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool calls fn for every user with at most workers concurrent calls.
+// Users not started before the context is done are left for the next run.
+func Pool(ctx context.Context, workers int, ids []int64, fn func(ctx context.Context, userID int64)) {
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		wg    sync.WaitGroup
+		queue = make(chan int64)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				fn(ctx, id)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(queue)
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return
+		case queue <- id:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"marathon_procrastination_bot/internal/logging"
+	"marathon_procrastination_bot/internal/tracing"
+)
+
+// Job is a periodic task. Runs are aligned to multiples of Every since the
+// Unix epoch, so an hourly job runs at the beginning of every UTC hour.
+type Job struct {
+	Name  string
+	Every time.Duration
+	// Retries is the number of additional attempts after a failed run.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for every next one.
+	Backoff time.Duration
+	// Run must be safe to repeat after a partial failure if Retries > 0.
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs jobs until the context is done.
+type Scheduler struct {
+	jobs []Job
+	// OnError reports the failed attempt of the job. Logs by default.
+	OnError func(ctx context.Context, job string, attempt int, err error)
+}
+
+func New(jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs: jobs,
+		OnError: func(ctx context.Context, job string, attempt int, err error) {
+			slog.ErrorContext(ctx, "job failed", logging.Job, job, "attempt", attempt, "error", err)
+		},
+	}
+}
+
+// Run starts every job in its own goroutine and blocks until the context
+// is done and all running jobs have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(job.Every).Add(job.Every).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run runs the job once, retrying failures with exponential backoff.
+// Every attempt is traced, so that calls of one run share the trace.
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	backoff := job.Backoff
+	for attempt := 1; ; attempt++ {
+		err := s.attempt(ctx, job, attempt)
+		if err == nil {
+			return
+		}
+		s.OnError(ctx, job.Name, attempt, err)
+		if attempt > job.Retries || ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Scheduler) attempt(ctx context.Context, job Job, attempt int) (err error) {
+	ctx, span := tracing.Start(ctx, "job."+job.Name,
+		trace.WithNewRoot(),
+		trace.WithAttributes(tracing.Attributes(logging.Job, job.Name, "attempt", attempt)...),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return job.Run(ctx)
+}
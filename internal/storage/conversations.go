@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+
+	"marathon_procrastination_bot/internal/conversation"
+)
+
+func (s *storage) UserConversation(ctx context.Context, userID int64) (state conversation.State, has bool, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT
+				COALESCE(flow, ""u),
+				COALESCE(arg, ""u),
+				COALESCE(prompt_id, 0),
+				COALESCE(expires_ts, CAST(0 AS Timestamp))
+			FROM conversations
+			WHERE user_id=$1;
+		`, userID)
+		var (
+			flow     string
+			promptID int64
+		)
+		if err := row.Scan(&flow, &state.Arg, &promptID, &state.Expires); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				has = false
+				return nil
+			}
+			return err
+		}
+		state.Flow = conversation.Flow(flow)
+		state.PromptID = int(promptID)
+		has = true
+		return row.Err()
+	})
+	return state, has, err
+}
+
+func (s *storage) SetUserConversation(ctx context.Context, userID int64, state conversation.State) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPSERT INTO conversations (
+				user_id, flow, arg, prompt_id, expires_ts
+			) VALUES (
+				$1, $2, $3, $4, $5
+			);`,
+			userID,
+			string(state.Flow),
+			state.Arg,
+			int64(state.PromptID),
+			state.Expires.UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) DeleteUserConversation(ctx context.Context, userID int64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM conversations
+			WHERE user_id=$1;`,
+			userID,
+		)
+		return err
+	})
+}
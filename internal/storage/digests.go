@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+
+	"marathon_procrastination_bot/internal/digest"
+)
+
+// UsersForDigest lists users for whom a digest is due now. Digests are chosen
+// by the rules of digest.Settings.Due, which DigestUser applies again per user.
+func (s *storage) UsersForDigest(ctx context.Context) (ids []int64, err error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	err = retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		ids = ids[:0]
+		rows, err := cc.QueryContext(ctx, `
+			$users = (
+				SELECT
+					user_id,
+					COALESCE(digest_hour, COALESCE(hour_to_rotate_stats, 0)) AS hour,
+					COALESCE(weekly_digest_weekday, $6) AS weekday,
+					COALESCE(monthly_digest, false) AS monthly,
+					COALESCE(weekly_digest_ts, CAST(0 AS Timestamp)) AS weekly_ts,
+					COALESCE(monthly_digest_ts, CAST(0 AS Timestamp)) AS monthly_ts
+				FROM users
+				WHERE COALESCE(inactive, false)=false
+			);
+			$moments = (
+				SELECT
+					user_id, weekday, monthly, weekly_ts, monthly_ts,
+					$2 + Interval("PT1H") * hour - Interval("P1D") * (($3 - weekday + 7) % 7) AS weekly_at,
+					$4 + Interval("PT1H") * hour AS monthly_at,
+					$5 + Interval("PT1H") * hour AS prev_monthly_at
+				FROM $users
+			);
+			SELECT user_id
+			FROM $moments
+			WHERE (weekday!=$6
+					AND weekly_ts<IF(weekly_at>$1, weekly_at - Interval("P7D"), weekly_at))
+				OR (monthly
+					AND monthly_ts<IF(monthly_at>$1, prev_monthly_at, monthly_at));
+		`,
+			now,
+			today,
+			int32(now.Weekday()),
+			month,
+			month.AddDate(0, -1, 0),
+			int32(digest.Off),
+		)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return rows.Err()
+	})
+	return ids, err
+}
+
+func (s *storage) UserDigestSettings(ctx context.Context, userID int64) (settings digest.Settings, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT
+				COALESCE(hour_to_rotate_stats, 0),
+				COALESCE(digest_hour, COALESCE(hour_to_rotate_stats, 0)),
+				COALESCE(weekly_digest_weekday, $2),
+				COALESCE(monthly_digest, false),
+				COALESCE(weekly_digest_ts, CAST(0 AS Timestamp)),
+				COALESCE(monthly_digest_ts, CAST(0 AS Timestamp))
+			FROM users
+			WHERE user_id=$1;
+		`, userID, int32(digest.Off))
+		if err := row.Scan(
+			&settings.RotateHour,
+			&settings.Hour,
+			&settings.Weekday,
+			&settings.Monthly,
+			&settings.WeeklySentTS,
+			&settings.MonthlySentTS,
+		); err != nil {
+			return err
+		}
+		return row.Err()
+	})
+	return settings, err
+}
+
+func (s *storage) UserPosts(ctx context.Context, userID int64, from, to time.Time) (posts []digest.Post, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		posts = posts[:0]
+		rows, err := cc.QueryContext(ctx, `
+			SELECT m.name, p.ts
+			FROM marathon_posts AS p
+			JOIN marathons AS m ON p.user_id=m.user_id AND p.activity_id=m.id
+			WHERE p.user_id=$1 AND p.ts>=$2 AND p.ts<$3
+			ORDER BY p.ts;
+		`, userID, from.UTC(), to.UTC())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var post digest.Post
+			if err := rows.Scan(&post.Activity, &post.TS); err != nil {
+				return err
+			}
+			posts = append(posts, post)
+		}
+		return rows.Err()
+	})
+	return posts, err
+}
+
+func (s *storage) SetUserWeeklyDigest(ctx context.Context, userID int64, weekday int32, hour int32) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		if weekday == digest.Off {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE users
+				SET weekly_digest_weekday=NULL, last_activity_ts=$2
+				WHERE user_id=$1;
+				`, userID, time.Now().UTC(),
+			)
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET weekly_digest_weekday=$2, digest_hour=$3, weekly_digest_ts=$4, last_activity_ts=$4
+			WHERE user_id=$1;
+			`, userID, weekday, hour, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) SetUserMonthlyDigest(ctx context.Context, userID int64, enabled bool) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET monthly_digest=$2, monthly_digest_ts=$3, last_activity_ts=$3
+			WHERE user_id=$1;
+			`, userID, enabled, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) MarkUserDigestSent(ctx context.Context, userID int64, kind digest.Kind) error {
+	var column string
+	switch kind {
+	case digest.Weekly:
+		column = "weekly_digest_ts"
+	case digest.Monthly:
+		column = "monthly_digest_ts"
+	default:
+		return fmt.Errorf("unknown digest kind %d", kind)
+	}
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users SET `+column+`=$2
+			WHERE user_id=$1;
+			`, userID, time.Now().UTC(),
+		)
+		return err
+	})
+}
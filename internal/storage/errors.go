@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors of the storage. Methods wrap them with details,
+// so check them with errors.Is.
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrActivityNotFound  = errors.New("activity not found")
+	ErrActivityExists    = errors.New("activity already exists")
+	ErrTooManyActivities = errors.New("too many active activities")
+	ErrNothingToUndo     = errors.New("nothing to undo")
+	ErrNoFreezesLeft     = errors.New("no freezes left")
+)
+
+// DuplicateError reports the existing activity whose name matches the new one
+// regardless of case and whitespace.
+type DuplicateError struct {
+	Existing Activity
+	Archived bool
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("activity %q already exists", e.Existing.Name)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrActivityExists
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+)
+
+// Failure is the record of consecutive failures of a batch job for the user.
+type Failure struct {
+	Count     uint64
+	Permanent bool
+}
+
+// UserFailures returns failures of the job by user.
+func (s *storage) UserFailures(ctx context.Context, job string) (failures map[int64]Failure, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		failures = make(map[int64]Failure)
+		rows, err := cc.QueryContext(ctx, `
+			SELECT user_id, COALESCE(count, 0ul), COALESCE(permanent, false)
+			FROM user_failures
+			WHERE job=$1;
+		`, job)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var (
+				id      int64
+				failure Failure
+			)
+			if err := rows.Scan(&id, &failure.Count, &failure.Permanent); err != nil {
+				return err
+			}
+			failures[id] = failure
+		}
+		return rows.Err()
+	})
+	return failures, err
+}
+
+// RecordUserFailure increments the count of consecutive failures of the job for the user.
+func (s *storage) RecordUserFailure(ctx context.Context, userID int64, job string, permanent bool, reason string) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(count, 0ul)
+			FROM user_failures
+			WHERE user_id=$1 AND job=$2;
+		`, userID, job)
+		var count uint64
+		if err := row.Scan(&count); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPSERT INTO user_failures (
+				user_id, job, count, permanent, error, ts
+			) VALUES (
+				$1, $2, $3, $4, $5, $6
+			);`, userID, job, count+1, permanent, reason, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+// ResetUserFailures forgets failures of the job for the user after a success.
+func (s *storage) ResetUserFailures(ctx context.Context, userID int64, job string) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM user_failures
+			WHERE user_id=$1 AND job=$2;`,
+			userID, job,
+		)
+		return err
+	})
+}
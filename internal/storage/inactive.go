@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+)
+
+// DeactivateUser excludes the user, who blocked the bot or whose chat is gone,
+// from notifications until the user messages the bot again.
+func (s *storage) DeactivateUser(ctx context.Context, userID int64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users SET inactive=true
+			WHERE user_id=$1;
+			`, userID,
+		)
+		return err
+	})
+}
+
+// ActivateUser brings the inactive user back to notifications and forgets
+// failures of batch jobs for the user. Returns whether the user was inactive.
+func (s *storage) ActivateUser(ctx context.Context, userID int64) (activated bool, _ error) {
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(inactive, false)
+			FROM users
+			WHERE user_id=$1;
+		`, userID)
+		var inactive bool
+		if err := row.Scan(&inactive); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				activated = false
+				return nil
+			}
+			return err
+		}
+		activated = inactive
+		if !inactive {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users SET inactive=false, last_activity_ts=$2
+			WHERE user_id=$1;
+			`, userID, time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM user_failures
+			WHERE user_id=$1;`,
+			userID,
+		)
+		return err
+	})
+	return activated, err
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+)
+
+// UserLanguage returns the language of the user or an empty string if it is not chosen.
+func (s *storage) UserLanguage(ctx context.Context, userID int64) (lang string, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT COALESCE(language, ""u)
+			FROM users
+			WHERE user_id=$1;
+		`, userID)
+		if err := row.Scan(&lang); err != nil {
+			return err
+		}
+		return row.Err()
+	})
+	return lang, err
+}
+
+func (s *storage) SetUserLanguage(ctx context.Context, userID int64, lang string) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET language=$2, last_activity_ts=$3
+			WHERE user_id=$1;
+			`, userID, lang, time.Now().UTC(),
+		)
+		return err
+	})
+}
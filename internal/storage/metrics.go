@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+
+	"marathon_procrastination_bot/internal/metrics"
+)
+
+// CountActive counts users who did not block the bot and their active marathons.
+func (s *storage) CountActive(ctx context.Context) (users uint64, marathons uint64, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM users
+			WHERE COALESCE(inactive, false)=false;
+		`)
+		if err := row.Scan(&users); err != nil {
+			return err
+		}
+		row = cc.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM marathons AS m
+			JOIN users AS u ON u.user_id=m.user_id
+			WHERE COALESCE(u.inactive, false)=false
+				AND COALESCE(m.archived, false)=false
+				AND m.deleted_ts IS NULL;
+		`)
+		return row.Scan(&marathons)
+	})
+	return users, marathons, err
+}
+
+// traceRetry measures every storage method by its retry loop: latency, retried
+// attempts and failures.
+func traceRetry() trace.Retry {
+	return trace.Retry{
+		OnRetry: func(info trace.RetryLoopStartInfo) func(trace.RetryLoopIntermediateInfo) func(trace.RetryLoopDoneInfo) {
+			if info.NestedCall {
+				return nil
+			}
+			method, start := caller(), time.Now()
+			ctx := context.Background()
+			if info.Context != nil {
+				ctx = *info.Context
+			}
+			return func(intermediate trace.RetryLoopIntermediateInfo) func(trace.RetryLoopDoneInfo) {
+				if intermediate.Error != nil {
+					slog.DebugContext(ctx, "storage retry", "method", method, "error", intermediate.Error)
+				}
+				return func(info trace.RetryLoopDoneInfo) {
+					metrics.StorageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+					if info.Attempts > 1 {
+						metrics.StorageRetries.WithLabelValues(method).Add(float64(info.Attempts - 1))
+					}
+					if info.Error != nil {
+						metrics.StorageErrors.WithLabelValues(method).Inc()
+						slog.WarnContext(ctx, "storage method failed",
+							"method", method, "attempts", info.Attempts, "error", info.Error)
+					}
+				}
+			}
+		},
+	}
+}
+
+// caller is the name of the storage method which runs the retry loop.
+// The loop reports retry.Do itself as the caller, so the method is found up the stack.
+func caller() string {
+	const prefix = "marathon_procrastination_bot/internal/storage.(*storage)."
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, found := strings.CutPrefix(frame.Function, prefix); found {
+			name, _, _ = strings.Cut(name, ".")
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN remind_hour Int32,
+    ADD COLUMN firm_remind_hours Int32,
+    ADD COLUMN last_call_remind Bool,
+    ADD COLUMN gentle_reminded_ts Timestamp,
+    ADD COLUMN firm_reminded_ts Timestamp,
+    ADD COLUMN last_call_reminded_ts Timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN remind_hour,
+    DROP COLUMN firm_remind_hours,
+    DROP COLUMN last_call_remind,
+    DROP COLUMN gentle_reminded_ts,
+    DROP COLUMN firm_reminded_ts,
+    DROP COLUMN last_call_reminded_ts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE activities
+    ADD COLUMN paused Bool,
+    ADD COLUMN frozen Bool;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE activities
+    DROP COLUMN paused,
+    DROP COLUMN frozen;
+-- +goose StatementEnd
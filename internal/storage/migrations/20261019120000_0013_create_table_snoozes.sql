@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE snoozes (
+    user_id Int64 NOT NULL,
+    until Timestamp,
+    PRIMARY KEY (user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE snoozes;
+-- +goose StatementEnd
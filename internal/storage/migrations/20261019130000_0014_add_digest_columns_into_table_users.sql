@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN digest_hour Int32,
+    ADD COLUMN weekly_digest_weekday Int32,
+    ADD COLUMN monthly_digest Bool,
+    ADD COLUMN weekly_digest_ts Timestamp,
+    ADD COLUMN monthly_digest_ts Timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN digest_hour,
+    DROP COLUMN weekly_digest_weekday,
+    DROP COLUMN monthly_digest,
+    DROP COLUMN weekly_digest_ts,
+    DROP COLUMN monthly_digest_ts;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE conversations (
+    user_id Int64 NOT NULL,
+    flow Text,
+    arg Text,
+    prompt_id Int64,
+    expires_ts Timestamp,
+    PRIMARY KEY (user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE conversations;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE activities
+    ADD COLUMN archived Bool,
+    ADD COLUMN deleted_ts Timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE activities
+    DROP COLUMN archived,
+    DROP COLUMN deleted_ts;
+-- +goose StatementEnd
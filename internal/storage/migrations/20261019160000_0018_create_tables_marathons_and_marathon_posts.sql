@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE marathons (
+    user_id Int64 NOT NULL,
+    id Uint64 NOT NULL,
+    name Text,
+    total Uint64,
+    current Uint64,
+    target Uint64,
+    post_ts Timestamp,
+    last_notificated Timestamp,
+    paused Bool,
+    frozen Bool,
+    archived Bool,
+    deleted_ts Timestamp,
+    PRIMARY KEY (user_id, id)
+);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TABLE marathon_posts (
+    user_id Int64 NOT NULL,
+    activity_id Uint64 NOT NULL,
+    ts Timestamp NOT NULL,
+    PRIMARY KEY (user_id, activity_id, ts)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE marathon_posts;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE marathons;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+UPSERT INTO marathons (
+    user_id, id, name, total, current, target, post_ts, last_notificated, paused, frozen, archived, deleted_ts
+)
+SELECT
+    user_id,
+    RandomNumber(user_id, activity) AS id,
+    activity AS name,
+    total, current, target, post_ts, last_notificated, paused, frozen, archived, deleted_ts
+FROM activities;
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPSERT INTO marathon_posts (
+    user_id, activity_id, ts
+)
+SELECT
+    p.user_id AS user_id,
+    m.id AS activity_id,
+    p.ts AS ts
+FROM posts AS p
+JOIN marathons AS m ON p.user_id=m.user_id AND p.activity=m.name
+WHERE p.ts IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPSERT INTO activities (
+    user_id, activity, total, current, target, post_ts, last_notificated, paused, frozen, archived, deleted_ts
+)
+SELECT
+    user_id, name AS activity, total, current, target, post_ts, last_notificated, paused, frozen, archived, deleted_ts
+FROM marathons;
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPSERT INTO posts (
+    user_id, activity, ts
+)
+SELECT
+    p.user_id AS user_id,
+    m.name AS activity,
+    p.ts AS ts
+FROM marathon_posts AS p
+JOIN marathons AS m ON p.user_id=m.user_id AND p.activity_id=m.id;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP TABLE posts;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE activities;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE activities (
+    user_id Int64 NOT NULL,
+    activity Text NOT NULL,
+    total Uint64,
+    current Uint64,
+    post_ts Timestamp,
+    last_notificated Timestamp,
+    paused Bool,
+    frozen Bool,
+    target Uint64,
+    archived Bool,
+    deleted_ts Timestamp,
+    PRIMARY KEY (user_id, activity)
+);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TABLE posts (
+    user_id Int64 NOT NULL,
+    activity Text NOT NULL,
+    ts Timestamp,
+    PRIMARY KEY (user_id, activity, ts)
+);
+-- +goose StatementEnd
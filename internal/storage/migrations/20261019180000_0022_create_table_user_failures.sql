@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_failures (
+    user_id Int64 NOT NULL,
+    job Text NOT NULL,
+    count Uint64,
+    permanent Bool,
+    error Text,
+    ts Timestamp,
+    PRIMARY KEY (user_id, job)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_failures;
+-- +goose StatementEnd
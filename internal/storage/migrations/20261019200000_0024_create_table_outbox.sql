@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox (
+    chat_id Int64 NOT NULL,
+    id Uint64 NOT NULL,
+    user_id Int64,
+    text Text,
+    keyboard Text,
+    attempts Uint32,
+    next_ts Timestamp,
+    PRIMARY KEY (chat_id, id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox;
+-- +goose StatementEnd
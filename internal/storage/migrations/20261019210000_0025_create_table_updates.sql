@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE updates (
+    update_id Int64 NOT NULL,
+    ts Timestamp,
+    PRIMARY KEY (update_id)
+) WITH (
+    TTL = Interval("P1D") ON ts
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE updates;
+-- +goose StatementEnd
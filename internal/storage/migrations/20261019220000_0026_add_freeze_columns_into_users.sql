@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN freezes_left Uint32,
+    ADD COLUMN freezes_period_ts Timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN freezes_left,
+    DROP COLUMN freezes_period_ts;
+-- +goose StatementEnd
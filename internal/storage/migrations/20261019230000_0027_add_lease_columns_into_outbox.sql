@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    ADD COLUMN locked_until Timestamp,
+    ADD COLUMN locked_by Text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP COLUMN locked_until,
+    DROP COLUMN locked_by;
+-- +goose StatementEnd
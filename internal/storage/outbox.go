@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"marathon_procrastination_bot/internal/outbox"
+)
+
+func (s *storage) EnqueueMessages(ctx context.Context, messages ...outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		return enqueueMessages(ctx, tx, messages)
+	})
+}
+
+// enqueueMessages stores numbered messages in the outbox within the transaction.
+func enqueueMessages(ctx context.Context, tx *sql.Tx, messages []outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	rows := make([]types.Value, len(messages))
+	now := time.Now().UTC()
+	for i, message := range messages {
+		var keyboard []byte
+		if message.Keyboard != nil {
+			var err error
+			if keyboard, err = json.Marshal(message.Keyboard); err != nil {
+				return err
+			}
+		}
+		rows[i] = types.StructValue(
+			types.StructFieldValue("chat_id", types.Int64Value(message.ChatID)),
+			types.StructFieldValue("id", types.Uint64Value(message.ID)),
+			types.StructFieldValue("user_id", types.Int64Value(message.UserID)),
+			types.StructFieldValue("text", types.TextValue(message.Text)),
+			types.StructFieldValue("keyboard", types.TextValue(string(keyboard))),
+			types.StructFieldValue("attempts", types.Uint32Value(0)),
+			types.StructFieldValue("next_ts", types.TimestampValueFromTime(now)),
+		)
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPSERT INTO outbox
+		SELECT * FROM AS_TABLE($1);`,
+		types.ListValue(rows...),
+	)
+	return err
+}
+
+// PendingMessages returns messages due for delivery in the order of delivery.
+func (s *storage) PendingMessages(ctx context.Context, limit int) (messages []outbox.Message, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		messages = messages[:0]
+		rows, err := cc.QueryContext(ctx, `
+			SELECT chat_id, id, COALESCE(user_id, 0), COALESCE(text, ""u), COALESCE(keyboard, ""u), COALESCE(attempts, 0u)
+			FROM outbox
+			WHERE COALESCE(next_ts, CAST(0 AS Timestamp))<=$1
+			ORDER BY chat_id, id
+			LIMIT $2;
+		`, time.Now().UTC(), uint64(limit))
+		if err != nil {
+			return err
+		}
+		messages, err = scanMessages(rows)
+		return err
+	})
+	return messages, err
+}
+
+// ClaimMessages leases messages due for delivery to the owner until the lease
+// ends, in the order of delivery. Chats with messages leased by anyone are
+// skipped, so that concurrent flushes neither send a message twice nor
+// reorder messages of a chat.
+func (s *storage) ClaimMessages(ctx context.Context, owner string, limit int, lease time.Duration) (messages []outbox.Message, _ error) {
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		messages = messages[:0]
+		now := time.Now().UTC()
+		rows, err := tx.QueryContext(ctx, `
+			SELECT chat_id, id, COALESCE(user_id, 0), COALESCE(text, ""u), COALESCE(keyboard, ""u), COALESCE(attempts, 0u)
+			FROM outbox
+			WHERE COALESCE(next_ts, CAST(0 AS Timestamp))<=$1
+				AND chat_id NOT IN (
+					SELECT chat_id
+					FROM outbox
+					WHERE COALESCE(locked_until, CAST(0 AS Timestamp))>$1
+				)
+			ORDER BY chat_id, id
+			LIMIT $2;
+		`, now, uint64(limit))
+		if err != nil {
+			return err
+		}
+		if messages, err = scanMessages(rows); err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		leased := make([]types.Value, len(messages))
+		for i, message := range messages {
+			leased[i] = types.StructValue(
+				types.StructFieldValue("chat_id", types.Int64Value(message.ChatID)),
+				types.StructFieldValue("id", types.Uint64Value(message.ID)),
+				types.StructFieldValue("locked_until", types.TimestampValueFromTime(now.Add(lease))),
+				types.StructFieldValue("locked_by", types.TextValue(owner)),
+			)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE outbox ON
+			SELECT * FROM AS_TABLE($1);`,
+			types.ListValue(leased...),
+		)
+		return err
+	})
+	return messages, err
+}
+
+// ReleaseOutboxMessages ends leases of the owner on messages it did not deliver.
+func (s *storage) ReleaseOutboxMessages(ctx context.Context, owner string) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE outbox SET locked_until=NULL, locked_by=NULL
+			WHERE locked_by=$1;`,
+			owner,
+		)
+		return err
+	})
+}
+
+func (s *storage) DeleteOutboxMessage(ctx context.Context, chatID int64, id uint64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM outbox
+			WHERE chat_id=$1 AND id=$2;`,
+			chatID, id,
+		)
+		return err
+	})
+}
+
+func (s *storage) PostponeOutboxMessages(ctx context.Context, chatID int64, id uint64, attempts uint32, until time.Time) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE outbox SET next_ts=$2
+			WHERE chat_id=$1 AND COALESCE(next_ts, CAST(0 AS Timestamp))<$2;
+			`, chatID, until.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE outbox SET attempts=$3
+			WHERE chat_id=$1 AND id=$2;
+			`, chatID, id, attempts,
+		)
+		return err
+	})
+}
+
+func scanMessages(rows *sql.Rows) (messages []outbox.Message, _ error) {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			message  outbox.Message
+			keyboard string
+		)
+		if err := rows.Scan(&message.ChatID, &message.ID, &message.UserID, &message.Text, &keyboard, &message.Attempts); err != nil {
+			return nil, err
+		}
+		if keyboard != "" {
+			message.Keyboard = &models.InlineKeyboardMarkup{}
+			if err := json.Unmarshal([]byte(keyboard), message.Keyboard); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
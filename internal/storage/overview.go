@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+
+	"marathon_procrastination_bot/internal/reminder"
+)
+
+// Marathon is an active (neither archived nor deleted) activity with its stats.
+type Marathon struct {
+	Activity
+	Total           uint64
+	Current         uint64
+	Target          uint64
+	Paused          bool
+	Frozen          bool
+	LastNotificated time.Time
+}
+
+// Posted reports whether the marathon is posted since the last stats rotation.
+func (m Marathon) Posted() bool {
+	return m.Current > 0
+}
+
+// Pending reports whether the user is still expected to post the marathon today.
+func (m Marathon) Pending() bool {
+	return m.Current == 0 && !m.Paused && !m.Frozen
+}
+
+// Overview is everything about the user which stats and reminders show.
+type Overview struct {
+	ChatID     int64
+	Language   string
+	RotateHour int32
+	// RemindHour is reminder.Off unless the user chose the hour of the gentle reminder.
+	RemindHour int32
+	// Marathons are ordered by name.
+	Marathons []Marathon
+}
+
+// Pending returns the marathons the user is still expected to post today.
+func (o Overview) Pending() (pending []Marathon) {
+	for _, m := range o.Marathons {
+		if m.Pending() {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// UserOverview loads the user with all active marathons and their stats in one query,
+// instead of UserActivities followed by a query of stats for every activity.
+func (s *storage) UserOverview(ctx context.Context, userID int64) (overview Overview, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		overview = Overview{}
+		rows, err := cc.QueryContext(ctx, `
+			SELECT
+				COALESCE(u.registration_chat_id, u.user_id),
+				COALESCE(u.language, ""u),
+				COALESCE(u.hour_to_rotate_stats, 0),
+				COALESCE(u.remind_hour, $2),
+				m.id IS NOT NULL,
+				COALESCE(m.id, 0ul),
+				COALESCE(m.name, ""u),
+				COALESCE(m.total, 0ul),
+				COALESCE(m.current, 0ul),
+				COALESCE(m.target, 0ul),
+				COALESCE(m.paused, false),
+				COALESCE(m.frozen, false),
+				COALESCE(m.last_notificated, CAST(0 AS Timestamp))
+			FROM users AS u
+			LEFT JOIN (
+				SELECT *
+				FROM marathons
+				WHERE user_id=$1
+					AND COALESCE(archived, false)=false
+					AND deleted_ts IS NULL
+			) AS m ON u.user_id=m.user_id
+			WHERE u.user_id=$1;
+		`, userID, int32(reminder.Off))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		found := false
+		for rows.Next() {
+			var (
+				marathon Marathon
+				has      bool
+			)
+			err := rows.Scan(
+				&overview.ChatID, &overview.Language, &overview.RotateHour, &overview.RemindHour,
+				&has, &marathon.ID, &marathon.Name,
+				&marathon.Total, &marathon.Current, &marathon.Target,
+				&marathon.Paused, &marathon.Frozen, &marathon.LastNotificated,
+			)
+			if err != nil {
+				return err
+			}
+			found = true
+			if has {
+				overview.Marathons = append(overview.Marathons, marathon)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
+		}
+		return nil
+	})
+	sort.Slice(overview.Marathons, func(i, j int) bool {
+		return overview.Marathons[i].Name < overview.Marathons[j].Name
+	})
+	return overview, err
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+
+	"marathon_procrastination_bot/internal/reminder"
+)
+
+// UsersForReminders lists users with pending marathons for whom a reminder
+// step is due now. Steps are chosen by the rules of reminder.Policy.Due,
+// which RemindUser applies again per user.
+func (s *storage) UsersForReminders(ctx context.Context) (ids []int64, err error) {
+	now := time.Now().UTC()
+	err = retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		ids = ids[:0]
+		rows, err := cc.QueryContext(ctx, `
+			$users = (
+				SELECT
+					user_id,
+					($1 - COALESCE(hour_to_rotate_stats, 0) + 24) % 24 AS hour_of_day,
+					COALESCE(hour_to_rotate_stats, 0) AS rotate_hour,
+					COALESCE(remind_hour, $4) AS remind_hour,
+					COALESCE(firm_remind_hours, $5) AS firm_hours,
+					COALESCE(last_call_remind, true) AS last_call,
+					COALESCE(gentle_reminded_ts, CAST(0 AS Timestamp)) AS gentle_ts,
+					COALESCE(firm_reminded_ts, CAST(0 AS Timestamp)) AS firm_ts,
+					COALESCE(last_call_reminded_ts, CAST(0 AS Timestamp)) AS last_call_ts
+				FROM users
+				WHERE COALESCE(inactive, false)=false
+			);
+			$windows = (
+				SELECT
+					user_id,
+					gentle_ts,
+					firm_ts,
+					last_call_ts,
+					$3 - Interval("PT1H") * hour_of_day AS day_start,
+					last_call AND (24-hour_of_day)*60-$2<=$6 AS last_call_open,
+					firm_hours>0 AND hour_of_day>=24-firm_hours AS firm_open,
+					remind_hour!=$4 AND hour_of_day>=(remind_hour-rotate_hour+24)%24 AS gentle_open
+				FROM $users
+			);
+			$due = (
+				SELECT user_id
+				FROM $windows
+				WHERE (last_call_open AND last_call_ts<day_start)
+					OR (NOT last_call_open AND firm_open
+						AND firm_ts<day_start AND last_call_ts<day_start)
+					OR (NOT last_call_open AND NOT firm_open AND gentle_open
+						AND gentle_ts<day_start AND firm_ts<day_start AND last_call_ts<day_start)
+			);
+			SELECT DISTINCT m.user_id AS user_id
+			FROM marathons AS m
+			JOIN $due AS d ON d.user_id=m.user_id
+			WHERE m.current=0
+				AND COALESCE(m.paused, false)=false
+				AND COALESCE(m.archived, false)=false
+				AND m.deleted_ts IS NULL
+				AND COALESCE(m.frozen, false)=false
+				AND m.user_id NOT IN (SELECT user_id FROM snoozes);
+		`,
+			int32(now.Hour()),
+			int32(now.Minute()),
+			now.Truncate(time.Hour),
+			int32(reminder.Off),
+			int32(reminder.DefaultFirmHours),
+			int32(reminder.LastCallBefore/time.Minute),
+		)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return rows.Err()
+	})
+	return ids, err
+}
+
+func (s *storage) UserReminderPolicy(ctx context.Context, userID int64) (policy reminder.Policy, state reminder.State, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT
+				COALESCE(hour_to_rotate_stats, 0),
+				COALESCE(remind_hour, $2),
+				COALESCE(firm_remind_hours, $3),
+				COALESCE(last_call_remind, true),
+				COALESCE(gentle_reminded_ts, CAST(0 AS Timestamp)),
+				COALESCE(firm_reminded_ts, CAST(0 AS Timestamp)),
+				COALESCE(last_call_reminded_ts, CAST(0 AS Timestamp))
+			FROM users
+			WHERE user_id=$1;
+		`, userID, int32(reminder.Off), int32(reminder.DefaultFirmHours))
+		if err := row.Scan(
+			&policy.RotateHour,
+			&policy.GentleHour,
+			&policy.FirmHours,
+			&policy.LastCall,
+			&state.Gentle,
+			&state.Firm,
+			&state.LastCall,
+		); err != nil {
+			return err
+		}
+		return row.Err()
+	})
+	return policy, state, err
+}
+
+func (s *storage) SetUserRemindHour(ctx context.Context, userID int64, hour int32) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		if hour == reminder.Off {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE users
+				SET remind_hour=NULL, last_activity_ts=$2
+				WHERE user_id=$1;
+				`, userID, time.Now().UTC(),
+			)
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET remind_hour=$2, last_activity_ts=$3
+			WHERE user_id=$1;
+			`, userID, hour, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) SetUserFirmRemindHours(ctx context.Context, userID int64, hours int32) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET firm_remind_hours=$2, last_activity_ts=$3
+			WHERE user_id=$1;
+			`, userID, hours, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) SetUserLastCallRemind(ctx context.Context, userID int64, enabled bool) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET last_call_remind=$2, last_activity_ts=$3
+			WHERE user_id=$1;
+			`, userID, enabled, time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (s *storage) MarkUserReminded(ctx context.Context, userID int64, step reminder.Step) error {
+	var column string
+	switch step {
+	case reminder.Gentle:
+		column = "gentle_reminded_ts"
+	case reminder.Firm:
+		column = "firm_reminded_ts"
+	case reminder.LastCall:
+		column = "last_call_reminded_ts"
+	default:
+		return fmt.Errorf("unknown reminder step %d", step)
+	}
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users SET `+column+`=$2
+			WHERE user_id=$1;
+			`, userID, time.Now().UTC(),
+		)
+		return err
+	})
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"marathon_procrastination_bot/internal/outbox"
+)
+
+const (
+	// rotationChunk is the number of users rotated in one transaction.
+	rotationChunk = 500
+	// rowsLimit is the limit of rows in the result of a data query of YDB,
+	// marathons of a chunk are read in pages of it.
+	rowsLimit = 1000
+)
+
+// Streak is the change of the streak of an activity at the stats rotation.
+type Streak struct {
+	Activity Activity
+	Before   uint64
+	After    uint64
+}
+
+// Broken reports whether the streak was reset because the day was missed.
+func (s Streak) Broken() bool {
+	return s.Before > 0 && s.After == 0
+}
+
+// Extended reports whether the activity was posted during the day.
+func (s Streak) Extended() bool {
+	return s.After > s.Before
+}
+
+// StreakMessages builds messages about streaks of the rotated users. They are
+// queued in the transaction of the rotation, so that they are not lost if the
+// rotation commits and the caller fails.
+type StreakMessages func(rotations []Rotation) []outbox.Message
+
+// Rotation is the outcome of the stats rotation for one user.
+type Rotation struct {
+	UserID   int64
+	ChatID   int64
+	Language string
+	Inactive bool
+	Streaks  []Streak
+}
+
+// RotateStats rotates stats of all users whose rotation hour it is, in chunks
+// of set-based statements instead of a transaction per user. Users already
+// rotated during the last day are left as is, so a failed run may be repeated.
+func (s *storage) RotateStats(ctx context.Context, hour int32, messages StreakMessages) (rotations []Rotation, _ error) {
+	for {
+		chunk, err := s.rotateChunk(ctx, hour, messages)
+		if err != nil {
+			return rotations, err
+		}
+		rotations = append(rotations, chunk...)
+		if len(chunk) < rotationChunk {
+			return rotations, nil
+		}
+	}
+}
+
+func (s *storage) rotateChunk(ctx context.Context, hour int32, messages StreakMessages) (rotations []Rotation, _ error) {
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		now := time.Now().UTC()
+		rows, err := tx.QueryContext(ctx, `
+			SELECT user_id, COALESCE(registration_chat_id, user_id), COALESCE(language, ""u), COALESCE(inactive, false)
+			FROM users
+			WHERE hour_to_rotate_stats=$1 AND last_stats_rotate_ts<$2
+			LIMIT $3;
+		`, hour, time.Unix(int64(now.Unix()/60/60-23)*60*60, 0).UTC(), uint64(rotationChunk))
+		if err != nil {
+			return err
+		}
+		if rotations, err = scanRotations(rows); err != nil {
+			return err
+		}
+		return rotate(ctx, tx, rotations, now, messages)
+	})
+	return rotations, err
+}
+
+// RotateUserStats rotates stats of the user right away, by the same rules as
+// RotateStats, regardless of the rotation hour.
+func (s *storage) RotateUserStats(ctx context.Context, userID int64, messages StreakMessages) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT user_id, COALESCE(registration_chat_id, user_id), COALESCE(language, ""u), COALESCE(inactive, false)
+			FROM users
+			WHERE user_id=$1;
+		`, userID)
+		if err != nil {
+			return err
+		}
+		rotations, err := scanRotations(rows)
+		if err != nil {
+			return err
+		}
+		if len(rotations) == 0 {
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
+		}
+		return rotate(ctx, tx, rotations, time.Now().UTC(), messages)
+	})
+}
+
+func scanRotations(rows *sql.Rows) (rotations []Rotation, _ error) {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var rotation Rotation
+		if err := rows.Scan(&rotation.UserID, &rotation.ChatID, &rotation.Language, &rotation.Inactive); err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rotation)
+	}
+	return rotations, rows.Err()
+}
+
+// rotate moves the current day into totals of activities of the users and
+// fills their streaks: a posted day extends the streak, a missed one resets it
+// unless the activity is paused, frozen or hidden. Messages about the streaks
+// are queued along.
+func rotate(ctx context.Context, tx *sql.Tx, rotations []Rotation, now time.Time, messages StreakMessages) error {
+	if len(rotations) == 0 {
+		return nil
+	}
+	var (
+		index = make(map[int64]int, len(rotations))
+		ids   = make([]types.Value, len(rotations))
+	)
+	for i, rotation := range rotations {
+		index[rotation.UserID] = i
+		ids[i] = types.Int64Value(rotation.UserID)
+	}
+	var (
+		marathons []types.Value
+		lastUser  int64 = math.MinInt64
+		lastID    uint64
+	)
+	for {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT
+				user_id, id, COALESCE(name, ""u),
+				COALESCE(total, 0ul), COALESCE(current, 0ul),
+				COALESCE(paused, false) OR COALESCE(frozen, false),
+				COALESCE(archived, false) OR deleted_ts IS NOT NULL
+			FROM marathons
+			WHERE user_id IN $1 AND (user_id>$2 OR (user_id=$2 AND id>$3))
+			ORDER BY user_id, id
+			LIMIT $4;
+		`, types.ListValue(ids...), lastUser, lastID, uint64(rowsLimit))
+		if err != nil {
+			return err
+		}
+		n := 0
+		for rows.Next() {
+			var (
+				userID          int64
+				streak          Streak
+				current         uint64
+				kept, invisible bool
+			)
+			if err := rows.Scan(&userID, &streak.Activity.ID, &streak.Activity.Name, &streak.Before, &current, &kept, &invisible); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			n++
+			lastUser, lastID = userID, streak.Activity.ID
+			streak.After = streak.Before + current
+			if current == 0 && !kept && !invisible {
+				streak.After = 0
+			}
+			marathons = append(marathons, types.StructValue(
+				types.StructFieldValue("user_id", types.Int64Value(userID)),
+				types.StructFieldValue("id", types.Uint64Value(streak.Activity.ID)),
+				types.StructFieldValue("total", types.Uint64Value(streak.After)),
+				types.StructFieldValue("current", types.Uint64Value(0)),
+				types.StructFieldValue("frozen", types.BoolValue(false)),
+			))
+			if !invisible {
+				r := &rotations[index[userID]]
+				r.Streaks = append(r.Streaks, streak)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		_ = rows.Close()
+		if n < rowsLimit {
+			break
+		}
+	}
+	if len(marathons) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons ON
+			SELECT * FROM AS_TABLE($1);`,
+			types.ListValue(marathons...),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	queued := messages(rotations)
+	outbox.Number(queued)
+	if err := enqueueMessages(ctx, tx, queued); err != nil {
+		return err
+	}
+	users := make([]types.Value, len(rotations))
+	for i, rotation := range rotations {
+		users[i] = types.StructValue(
+			types.StructFieldValue("user_id", types.Int64Value(rotation.UserID)),
+			types.StructFieldValue("last_stats_rotate_ts", types.TimestampValueFromTime(now)),
+		)
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE users ON
+		SELECT * FROM AS_TABLE($1);`,
+		types.ListValue(users...),
+	)
+	return err
+}
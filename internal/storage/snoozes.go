@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+)
+
+// SnoozeUser postpones notifications of the user until the given moment.
+// The user is pinged again by the scheduler as soon as the snooze expires.
+func (s *storage) SnoozeUser(ctx context.Context, userID int64, until time.Time) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPSERT INTO snoozes (
+				user_id, until
+			) VALUES (
+				$1, $2
+			);`, userID, until.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET last_activity_ts=$1
+			WHERE user_id=$2;
+			`, time.Now().UTC(), userID,
+		)
+		return err
+	})
+}
+
+func (s *storage) UsersWithExpiredSnooze(ctx context.Context) (ids []int64, err error) {
+	err = retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		ids = ids[:0]
+		rows, err := cc.QueryContext(ctx, `
+			SELECT user_id
+			FROM snoozes
+			WHERE until<=$1;
+		`, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return rows.Err()
+	})
+	return ids, err
+}
+
+func (s *storage) DeleteUserSnooze(ctx context.Context, userID int64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM snoozes
+			WHERE user_id=$1;`,
+			userID,
+		)
+		return err
+	})
+}
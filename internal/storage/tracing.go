@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"go.opentelemetry.io/otel/attribute"
+	otel "go.opentelemetry.io/otel/trace"
+
+	"marathon_procrastination_bot/internal/tracing"
+)
+
+// spanRetry traces every storage method by its retry loop, with an event per
+// failed attempt. Queries of the method are traced as children of its span.
+func spanRetry() trace.Retry {
+	return trace.Retry{
+		OnRetry: func(info trace.RetryLoopStartInfo) func(trace.RetryLoopIntermediateInfo) func(trace.RetryLoopDoneInfo) {
+			if info.NestedCall || info.Context == nil {
+				return nil
+			}
+			ctx, span := tracing.Start(*info.Context, "storage."+caller(),
+				otel.WithSpanKind(otel.SpanKindClient),
+				otel.WithAttributes(attribute.Bool("idempotent", info.Idempotent)),
+			)
+			*info.Context = ctx
+			return func(intermediate trace.RetryLoopIntermediateInfo) func(trace.RetryLoopDoneInfo) {
+				if intermediate.Error != nil {
+					span.AddEvent("attempt failed", otel.WithAttributes(attribute.String("error", intermediate.Error.Error())))
+				}
+				return func(info trace.RetryLoopDoneInfo) {
+					span.SetAttributes(attribute.Int("attempts", info.Attempts))
+					tracing.End(span, info.Error)
+				}
+			}
+		},
+	}
+}
+
+// spanSQL traces queries, transactions and YDB sessions which database/sql
+// opens for its connections, so that slow session creation is visible.
+func spanSQL() trace.DatabaseSQL {
+	return trace.DatabaseSQL{
+		OnConnectorConnect: func(info trace.DatabaseSQLConnectorConnectStartInfo) func(trace.DatabaseSQLConnectorConnectDoneInfo) {
+			_, span := tracing.Start(*info.Context, "ydb.CreateSession", otel.WithSpanKind(otel.SpanKindClient))
+			return func(info trace.DatabaseSQLConnectorConnectDoneInfo) {
+				if info.Session != nil {
+					span.SetAttributes(
+						attribute.String("ydb.session.id", info.Session.ID()),
+						attribute.Int64("ydb.node.id", int64(info.Session.NodeID())),
+					)
+				}
+				tracing.End(span, info.Error)
+			}
+		},
+		OnConnBegin: func(info trace.DatabaseSQLConnBeginStartInfo) func(trace.DatabaseSQLConnBeginDoneInfo) {
+			_, span := tracing.Start(*info.Context, "ydb.Begin", otel.WithSpanKind(otel.SpanKindClient))
+			return func(info trace.DatabaseSQLConnBeginDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+		OnConnQuery: func(info trace.DatabaseSQLConnQueryStartInfo) func(trace.DatabaseSQLConnQueryDoneInfo) {
+			span := query(info.Context, "ydb.Query", info.Query, attribute.Int64("ydb.session.idle_ms", info.IdleTime.Milliseconds()))
+			return func(info trace.DatabaseSQLConnQueryDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+		OnConnExec: func(info trace.DatabaseSQLConnExecStartInfo) func(trace.DatabaseSQLConnExecDoneInfo) {
+			span := query(info.Context, "ydb.Exec", info.Query, attribute.Int64("ydb.session.idle_ms", info.IdleTime.Milliseconds()))
+			return func(info trace.DatabaseSQLConnExecDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+		OnTxQuery: func(info trace.DatabaseSQLTxQueryStartInfo) func(trace.DatabaseSQLTxQueryDoneInfo) {
+			span := query(info.Context, "ydb.Query", info.Query)
+			return func(info trace.DatabaseSQLTxQueryDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+		OnTxExec: func(info trace.DatabaseSQLTxExecStartInfo) func(trace.DatabaseSQLTxExecDoneInfo) {
+			span := query(info.Context, "ydb.Exec", info.Query)
+			return func(info trace.DatabaseSQLTxExecDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+		OnTxCommit: func(info trace.DatabaseSQLTxCommitStartInfo) func(trace.DatabaseSQLTxCommitDoneInfo) {
+			_, span := tracing.Start(*info.Context, "ydb.Commit", otel.WithSpanKind(otel.SpanKindClient))
+			return func(info trace.DatabaseSQLTxCommitDoneInfo) {
+				tracing.End(span, info.Error)
+			}
+		},
+	}
+}
+
+// query starts the span of the query. Arguments are not recorded, only the text.
+func query(ctx *context.Context, name, text string, attrs ...attribute.KeyValue) otel.Span {
+	_, span := tracing.Start(*ctx, name,
+		otel.WithSpanKind(otel.SpanKindClient),
+		otel.WithAttributes(append(attrs, attribute.String("db.statement", text))...),
+	)
+	return span
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+)
+
+// ClaimUpdate records the update as being processed. Returns false if the update
+// was already claimed, e.g. Telegram redelivered it after a slow webhook response.
+// Claims expire by TTL of the table, which is far longer than Telegram keeps retrying.
+func (s *storage) ClaimUpdate(ctx context.Context, updateID int64) (claimed bool, _ error) {
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			SELECT update_id
+			FROM updates
+			WHERE update_id=$1;
+		`, updateID)
+		var id int64
+		if err := row.Scan(&id); err == nil {
+			claimed = false
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPSERT INTO updates (update_id, ts)
+			VALUES ($1, $2);
+			`, updateID, time.Now().UTC(),
+		)
+		claimed = err == nil
+		return err
+	})
+	return claimed, err
+}
+
+// ReleaseUpdate forgets the claim of the update, so that a redelivery of the update
+// is processed again after its processing failed.
+func (s *storage) ReleaseUpdate(ctx context.Context, updateID int64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM updates
+			WHERE update_id=$1;`,
+			updateID,
+		)
+		return err
+	})
+}
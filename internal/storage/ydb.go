@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"math/rand"
 	"os"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
 
 	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/validation"
 )
 
 //go:embed migrations/*.sql
@@ -26,6 +29,10 @@ func New(ctx context.Context) (*storage, error) {
 		os.Getenv(env.YDB_CONNECTION_STRING),
 		environ.WithEnvironCredentials(ctx),
 		ydb.WithBalancer(balancers.SingleConn()),
+		// spans first, so that logs of storage methods carry the trace
+		ydb.WithTraceRetry(spanRetry()),
+		ydb.WithTraceRetry(traceRetry()),
+		ydb.WithTraceDatabaseSQL(spanSQL()),
 	)
 	if err != nil {
 		return nil, err
@@ -45,6 +52,32 @@ func New(ctx context.Context) (*storage, error) {
 }
 
 func (s *storage) UpdateSchema() error {
+	return s.migrations(func(db *sql.DB) error {
+		return goose.Up(db, "migrations")
+	})
+}
+
+// PendingMigrations returns versions of migrations which UpdateSchema would apply.
+func (s *storage) PendingMigrations() (versions []int64, _ error) {
+	err := s.migrations(func(db *sql.DB) error {
+		current, err := goose.GetDBVersion(db)
+		if err != nil {
+			return err
+		}
+		migrations, err := goose.CollectMigrations("migrations", current, goose.MaxVersion)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			versions = append(versions, migration.Version)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// migrations runs fn over the connection suitable for goose.
+func (s *storage) migrations(fn func(db *sql.DB) error) error {
 	connector, err := ydb.Connector(s.native,
 		ydb.WithDefaultQueryMode(ydb.ScriptingQueryMode),
 		ydb.WithFakeTx(ydb.ScriptingQueryMode),
@@ -62,10 +95,7 @@ func (s *storage) UpdateSchema() error {
 	if err := goose.SetDialect("ydb"); err != nil {
 		return err
 	}
-	if err := goose.Up(db, "migrations"); err != nil {
-		return err
-	}
-	return nil
+	return fn(db)
 }
 
 type storage struct {
@@ -73,6 +103,29 @@ type storage struct {
 	db     *sql.DB
 }
 
+// Activity is a marathon of the user. The name is an editable label,
+// the activity is referenced by ID everywhere else.
+type Activity struct {
+	ID   uint64
+	Name string
+}
+
+func newActivityID() uint64 {
+	return rand.Uint64()
+}
+
+func scanActivities(rows *sql.Rows) (activities []Activity, _ error) {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var activity Activity
+		if err := rows.Scan(&activity.ID, &activity.Name); err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
 func (s *storage) UsersForRotate(ctx context.Context, hour int32) (ids []int64, err error) {
 	err = retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
 		ids = ids[:0]
@@ -101,10 +154,18 @@ func (s *storage) UsersForNotification(ctx context.Context) (ids []int64, err er
 	err = retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
 		ids = ids[:0]
 		rows, err := cc.QueryContext(ctx, `
-			SELECT DISTINCT user_id 
-			FROM activities 
-			WHERE current=0 
-				AND COALESCE(last_notificated, CAST(0 AS Timestamp))<CAST($1 AS Timestamp);
+			SELECT DISTINCT a.user_id 
+			FROM marathons AS a
+			JOIN users AS u ON a.user_id=u.user_id
+			WHERE a.current=0 
+				AND COALESCE(a.paused, false)=false
+				AND COALESCE(a.archived, false)=false
+				AND a.deleted_ts IS NULL
+				AND COALESCE(a.frozen, false)=false
+				AND COALESCE(a.last_notificated, CAST(0 AS Timestamp))<CAST($1 AS Timestamp)
+				AND u.remind_hour IS NULL
+				AND COALESCE(u.inactive, false)=false
+				AND a.user_id NOT IN (SELECT user_id FROM snoozes);
 			`, time.Now().UTC().Add(-time.Duration(env.FreezeHours())*time.Hour),
 		)
 		if err != nil {
@@ -129,9 +190,10 @@ func (s *storage) UsersWithoutActivities(ctx context.Context) (ids []int64, err
 		rows, err := cc.QueryContext(ctx, `
 			SELECT user_id 
 			FROM users
-			WHERE user_id NOT IN(
+			WHERE COALESCE(inactive, false)=false AND user_id NOT IN(
 			    SELECT DISTINCT user_id
-				FROM activities 
+				FROM marathons 
+				WHERE deleted_ts IS NULL
 			)
 			`, time.Now().UTC().Add(-time.Duration(env.FreezeHours())*time.Hour),
 		)
@@ -151,51 +213,6 @@ func (s *storage) UsersWithoutActivities(ctx context.Context) (ids []int64, err
 	return ids, err
 }
 
-func (s *storage) RotateUserStats(ctx context.Context, userID int64) error {
-	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
-		row := tx.QueryRowContext(ctx, `
-			SELECT COUNT(*)
-			FROM users
-			WHERE user_id=$1;
-		`, userID)
-		var count uint64
-		if err := row.Scan(&count); err != nil {
-			return err
-		}
-		if err := row.Err(); err != nil {
-			return err
-		}
-		if count == 0 {
-			return fmt.Errorf("user %d not found")
-		}
-		_, err := tx.ExecContext(ctx, `
-			UPDATE activities SET total=0
-			WHERE user_id=$1 AND current=0;
-			`, userID,
-		)
-		if err != nil {
-			return err
-		}
-		_, err = tx.ExecContext(ctx, `
-			UPDATE activities SET total=total+current, current=0
-			WHERE user_id=$1 AND current>0;
-			`, userID,
-		)
-		if err != nil {
-			return err
-		}
-		_, err = tx.ExecContext(ctx, `
-			UPDATE users SET last_stats_rotate_ts=$1
-			WHERE user_id=$1;
-			`, time.Now().UTC(), userID,
-		)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-}
-
 func (s *storage) SetUserRotateHour(ctx context.Context, userID int64, hour int32) error {
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		row := tx.QueryRowContext(ctx, `
@@ -211,7 +228,7 @@ func (s *storage) SetUserRotateHour(ctx context.Context, userID int64, hour int3
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found")
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
 		_, err := tx.ExecContext(ctx, `
 			UPDATE users 
@@ -226,45 +243,23 @@ func (s *storage) SetUserRotateHour(ctx context.Context, userID int64, hour int3
 	})
 }
 
-func (s *storage) UserStats(ctx context.Context, userID int64, activity string) (total uint64, current uint64, _ error) {
-	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
-		row := tx.QueryRowContext(ctx, `
-			SELECT COUNT(*)
-			FROM users
-			WHERE user_id=$1;
-		`, userID)
-		var count uint64
-		if err := row.Scan(&count); err != nil {
-			return err
-		}
-		if err := row.Err(); err != nil {
-			return err
-		}
-		if count == 0 {
-			return fmt.Errorf("user %d not found")
-		}
-		row = tx.QueryRowContext(ctx, `
-			SELECT total, current 
-			FROM activities 
-			WHERE user_id=$1 AND activity=$2;`,
-			userID, activity,
-		)
-		if err := row.Scan(&total, &current); err != nil {
-			return err
-		}
-		return row.Err()
-	})
-	return total, current, err
-}
-
-func (s *storage) AddUser(ctx context.Context, userID int64, chatID int64) error {
+func (s *storage) AddUser(ctx context.Context, userID int64, chatID int64, lang string) error {
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		_, err := tx.ExecContext(ctx, `
 			UPSERT INTO users (
-				user_id, hour_to_rotate_stats, registration_chat_id, last_activity_ts
+				user_id, hour_to_rotate_stats, registration_chat_id, last_activity_ts, language
 			) VALUES (
-				$1, $2, $3, $4
-			);`, userID, 0, chatID, time.Now().UTC(),
+				$1, $2, $3, $4, $5
+			);`, userID, 0, chatID, time.Now().UTC(), lang,
+		)
+		if err != nil {
+			return err
+		}
+		// a returning user gets a clean slate in batch jobs
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM user_failures
+			WHERE user_id=$1;`,
+			userID,
 		)
 		if err != nil {
 			return err
@@ -288,7 +283,7 @@ func (s *storage) RemoveUser(ctx context.Context, userID int64) error {
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found", userID)
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
 		_, err := tx.ExecContext(ctx, `
 			DELETE FROM users 
@@ -299,7 +294,23 @@ func (s *storage) RemoveUser(ctx context.Context, userID int64) error {
 			return err
 		}
 		_, err = tx.ExecContext(ctx, `
-			DELETE FROM activities 
+			DELETE FROM marathons 
+			WHERE user_id=$1;`,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM snoozes 
+			WHERE user_id=$1;`,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM conversations 
 			WHERE user_id=$1;`,
 			userID,
 		)
@@ -325,7 +336,7 @@ func (s *storage) UserRegistrationChatID(ctx context.Context, userID int64) (cha
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found")
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
 		row = tx.QueryRowContext(ctx, `
 			SELECT COALESCE(registration_chat_id, $1)
@@ -340,7 +351,7 @@ func (s *storage) UserRegistrationChatID(ctx context.Context, userID int64) (cha
 	return chatID, err
 }
 
-func (s *storage) UserActivities(ctx context.Context, userID int64) (activities []string, _ error) {
+func (s *storage) UserActivities(ctx context.Context, userID int64) (activities []Activity, _ error) {
 	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		row := tx.QueryRowContext(ctx, `
 			SELECT COUNT(*)
@@ -355,45 +366,60 @@ func (s *storage) UserActivities(ctx context.Context, userID int64) (activities
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found", userID)
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
-		activities = activities[:0]
 		rows, err := tx.QueryContext(ctx,
-			`SELECT activity 
-					FROM activities 
-					WHERE user_id=$1 ORDER BY activity;`,
+			`SELECT id, name 
+					FROM marathons 
+					WHERE user_id=$1
+						AND COALESCE(archived, false)=false
+						AND deleted_ts IS NULL
+					ORDER BY name;`,
 			userID,
 		)
 		if err != nil {
 			return err
 		}
-		for rows.Next() {
-			var activity string
-			if err := rows.Scan(&activity); err != nil {
-				return err
+		activities, err = scanActivities(rows)
+		return err
+	})
+	return activities, err
+}
+
+// UserActivity returns the activity of the user by ID, including archived
+// and recently deleted ones.
+func (s *storage) UserActivity(ctx context.Context, userID int64, activityID uint64) (activity Activity, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		row := cc.QueryRowContext(ctx, `
+			SELECT id, COALESCE(name, ""u)
+			FROM marathons
+			WHERE user_id=$1 AND id=$2;
+		`, userID, activityID)
+		if err := row.Scan(&activity.ID, &activity.Name); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("activity %d of user %d: %w", activityID, userID, ErrActivityNotFound)
 			}
-			activities = append(activities, activity)
+			return err
 		}
-		return rows.Err()
+		return row.Err()
 	})
-	return activities, err
+	return activity, err
 }
 
-func (s *storage) UpdateUserActivityLastNotificated(ctx context.Context, userID int64, activities ...string) error {
+func (s *storage) UpdateUserActivityLastNotificated(ctx context.Context, userID int64, activityIDs ...uint64) error {
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		_, err := tx.ExecContext(ctx, `
-			UPSERT INTO activities (
-			    user_id, activity, last_notificated
-			) SELECT user_id, activity, last_notificated FROM AS_TABLE($1);`,
+			UPDATE marathons ON
+			SELECT user_id, id, last_notificated FROM AS_TABLE($1);`,
 			func() types.Value {
 				var (
 					lastNotificated = time.Now().UTC()
-					rows            = make([]types.Value, len(activities))
+					rows            = make([]types.Value, len(activityIDs))
 				)
-				for i, activity := range activities {
+				for i, id := range activityIDs {
 					rows[i] = types.StructValue(
 						types.StructFieldValue("user_id", types.Int64Value(userID)),
-						types.StructFieldValue("activity", types.TextValue(activity)),
+						types.StructFieldValue("id", types.Uint64Value(id)),
 						types.StructFieldValue("last_notificated", types.TimestampValueFromTime(lastNotificated)),
 					)
 				}
@@ -407,7 +433,9 @@ func (s *storage) UpdateUserActivityLastNotificated(ctx context.Context, userID
 	})
 }
 
-func (s *storage) PostUserActivity(ctx context.Context, userID int64, activity string) error {
+// PostUserActivity records participation in the activity, which must be
+// neither archived nor deleted.
+func (s *storage) PostUserActivity(ctx context.Context, userID int64, activityID uint64) error {
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		row := tx.QueryRowContext(ctx, `
 			SELECT COUNT(*)
@@ -422,14 +450,28 @@ func (s *storage) PostUserActivity(ctx context.Context, userID int64, activity s
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found")
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
+		}
+		// a stale keyboard may reference an archived or deleted activity
+		row = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM marathons
+			WHERE user_id=$1 AND id=$2
+				AND deleted_ts IS NULL
+				AND COALESCE(archived, false)=false;
+		`, userID, activityID)
+		if err := row.Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("activity %d of user %d: %w", activityID, userID, ErrActivityNotFound)
 		}
 		_, err := tx.ExecContext(ctx, `
-			UPDATE activities 
-			SET current=current+1, post_ts=$3
-            WHERE user_id=$1 AND activity=$2;`,
+			UPDATE marathons 
+			SET current=current+1, post_ts=$3, paused=false
+            WHERE user_id=$1 AND id=$2;`,
 			userID,
-			activity,
+			activityID,
 			time.Now().UTC(),
 		)
 		if err != nil {
@@ -444,13 +486,13 @@ func (s *storage) PostUserActivity(ctx context.Context, userID int64, activity s
 			return err
 		}
 		_, err = tx.ExecContext(ctx, `
-			UPSERT INTO posts (
-			    user_id, activity, ts
+			UPSERT INTO marathon_posts (
+			    user_id, activity_id, ts
 			) VALUES (
 			    $1, $2, $3
 			);`,
 			userID,
-			activity,
+			activityID,
 			time.Now().UTC(),
 		)
 		if err != nil {
@@ -460,7 +502,49 @@ func (s *storage) PostUserActivity(ctx context.Context, userID int64, activity s
 	})
 }
 
-func (s *storage) NewUserActivity(ctx context.Context, userID int64, activity string) error {
+// UndoLastUserPost reverts the most recent post of the user made no longer
+// than the undo window ago and returns its activity.
+func (s *storage) UndoLastUserPost(ctx context.Context, userID int64) (activity Activity, _ error) {
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		var ts time.Time
+		row := tx.QueryRowContext(ctx, `
+			SELECT m.id, COALESCE(m.name, ""u), p.ts
+			FROM marathon_posts AS p
+			JOIN marathons AS m ON p.user_id=m.user_id AND p.activity_id=m.id
+			WHERE p.user_id=$1 AND p.ts>=$2 AND m.current>0 AND m.deleted_ts IS NULL
+			ORDER BY p.ts DESC
+			LIMIT 1;
+		`, userID, time.Now().UTC().Add(-time.Duration(env.UndoWindowMinutes())*time.Minute))
+		if err := row.Scan(&activity.ID, &activity.Name, &ts); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no recent posts of user %d: %w", userID, ErrNothingToUndo)
+			}
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET current=current-1
+			WHERE user_id=$1 AND id=$2;
+			`, userID, activity.ID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM marathon_posts ON
+			SELECT $1 AS user_id, $2 AS activity_id, $3 AS ts;
+			`, userID, activity.ID, ts,
+		)
+		return err
+	})
+	return activity, err
+}
+
+// NewUserActivity creates the activity with the validated and normalized name.
+func (s *storage) NewUserActivity(ctx context.Context, userID int64, name string) error {
+	name, err := validation.ActivityName(name)
+	if err != nil {
+		return err
+	}
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		row := tx.QueryRowContext(ctx, `
 			SELECT COUNT(*)
@@ -475,14 +559,21 @@ func (s *storage) NewUserActivity(ctx context.Context, userID int64, activity st
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found")
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
-		_, err := tx.ExecContext(ctx, `
-			INSERT INTO activities (
-				user_id, activity, total, current
+		active, err := checkActivityName(ctx, tx, userID, 0, name)
+		if err != nil {
+			return err
+		}
+		if active >= env.MaxActivities() {
+			return fmt.Errorf("user %d has %d activities: %w", userID, active, ErrTooManyActivities)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO marathons (
+				user_id, id, name, total, current
 			) VALUES (
-				$1, $2, 0, 0
-			);`, userID, activity,
+				$1, $2, $3, 0, 0
+			);`, userID, newActivityID(), name,
 		)
 		if err != nil {
 			return err
@@ -499,7 +590,7 @@ func (s *storage) NewUserActivity(ctx context.Context, userID int64, activity st
 	})
 }
 
-func (s *storage) DeleteUserActivity(ctx context.Context, userID int64, activity string) error {
+func (s *storage) DeleteUserActivity(ctx context.Context, userID int64, activityID uint64) error {
 	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
 		row := tx.QueryRowContext(ctx, `
 			SELECT COUNT(*)
@@ -514,13 +605,14 @@ func (s *storage) DeleteUserActivity(ctx context.Context, userID int64, activity
 			return err
 		}
 		if count == 0 {
-			return fmt.Errorf("user %d not found")
+			return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
 		_, err := tx.ExecContext(ctx, `
-			DELETE FROM activities 
-			WHERE user_id=$1 AND activity=$2;`,
+			UPDATE marathons SET deleted_ts=$3
+			WHERE user_id=$1 AND id=$2;`,
 			userID,
-			activity,
+			activityID,
+			time.Now().UTC(),
 		)
 		if err != nil {
 			return err
@@ -536,3 +628,283 @@ func (s *storage) DeleteUserActivity(ctx context.Context, userID int64, activity
 		return nil
 	})
 }
+
+func (s *storage) PauseUserActivity(ctx context.Context, userID int64, activityID uint64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET paused=true
+			WHERE user_id=$1 AND id=$2;
+			`, userID, activityID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET last_activity_ts=$1
+			WHERE user_id=$2;
+			`, time.Now().UTC(), userID,
+		)
+		return err
+	})
+}
+
+// FreezeUserActivities keeps the streaks of not yet posted activities
+// on the next stats rotation. Every freeze spends one of FREEZES_PER_MONTH
+// freezes of the calendar month, so the rest are returned. Freezing again
+// when nothing is left to freeze spends nothing.
+func (s *storage) FreezeUserActivities(ctx context.Context, userID int64) (left uint32, _ error) {
+	now := time.Now().UTC()
+	period := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	err := retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		var periodTs time.Time
+		row := tx.QueryRowContext(ctx, `
+			SELECT
+				COALESCE(freezes_left, 0u),
+				COALESCE(freezes_period_ts, CAST(0 AS Timestamp))
+			FROM users
+			WHERE user_id=$1;
+		`, userID)
+		if err := row.Scan(&left, &periodTs); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
+			}
+			return err
+		}
+		if periodTs.Before(period) {
+			left = uint32(env.FreezesPerMonth())
+		}
+		var pending uint64
+		row = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM marathons
+			WHERE user_id=$1
+				AND current=0
+				AND COALESCE(paused, false)=false
+				AND COALESCE(frozen, false)=false
+				AND COALESCE(archived, false)=false
+				AND deleted_ts IS NULL;
+		`, userID)
+		if err := row.Scan(&pending); err != nil {
+			return err
+		}
+		if pending == 0 {
+			return nil
+		}
+		if left == 0 {
+			return fmt.Errorf("user %d: %w", userID, ErrNoFreezesLeft)
+		}
+		left--
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET frozen=true
+			WHERE user_id=$1
+				AND current=0
+				AND COALESCE(paused, false)=false
+				AND COALESCE(archived, false)=false
+				AND deleted_ts IS NULL;
+			`, userID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET freezes_left=$2, freezes_period_ts=$3, last_activity_ts=$4
+			WHERE user_id=$1;
+			`, userID, left, period, now,
+		)
+		return err
+	})
+	return left, err
+}
+
+// RenameUserActivity changes the display name only, posts reference the activity by ID.
+func (s *storage) RenameUserActivity(ctx context.Context, userID int64, activityID uint64, name string) error {
+	name, err := validation.ActivityName(name)
+	if err != nil {
+		return err
+	}
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		if _, err := checkActivityName(ctx, tx, userID, activityID, name); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET name=$3
+			WHERE user_id=$1 AND id=$2 AND deleted_ts IS NULL;
+			`, userID, activityID, name,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET last_activity_ts=$1
+			WHERE user_id=$2;
+			`, time.Now().UTC(), userID,
+		)
+		return err
+	})
+}
+
+func (s *storage) SetUserActivityTarget(ctx context.Context, userID int64, activityID uint64, target uint64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET target=$3
+			WHERE user_id=$1 AND id=$2;
+			`, userID, activityID, target,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET last_activity_ts=$1
+			WHERE user_id=$2;
+			`, time.Now().UTC(), userID,
+		)
+		return err
+	})
+}
+
+// SetUserActivityArchived hides the activity or brings it back, unless the user
+// already has the maximal number of active activities.
+func (s *storage) SetUserActivityArchived(ctx context.Context, userID int64, activityID uint64, archived bool) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		if !archived {
+			row := tx.QueryRowContext(ctx, `
+				SELECT COUNT(*)
+				FROM marathons
+				WHERE user_id=$1 AND deleted_ts IS NULL AND COALESCE(archived, false)=false;
+			`, userID)
+			var active uint64
+			if err := row.Scan(&active); err != nil {
+				return err
+			}
+			if active >= uint64(env.MaxActivities()) {
+				return fmt.Errorf("user %d has %d activities: %w", userID, active, ErrTooManyActivities)
+			}
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE marathons SET archived=$3
+			WHERE user_id=$1 AND id=$2 AND deleted_ts IS NULL;
+			`, userID, activityID, archived,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET last_activity_ts=$1
+			WHERE user_id=$2;
+			`, time.Now().UTC(), userID,
+		)
+		return err
+	})
+}
+
+func (s *storage) UserArchivedActivities(ctx context.Context, userID int64) (activities []Activity, _ error) {
+	err := retry.Do(ctx, s.db, func(ctx context.Context, cc *sql.Conn) error {
+		rows, err := cc.QueryContext(ctx, `
+			SELECT id, name
+			FROM marathons
+			WHERE user_id=$1
+				AND COALESCE(archived, false)=true
+				AND deleted_ts IS NULL
+			ORDER BY name;`,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+		activities, err = scanActivities(rows)
+		return err
+	})
+	return activities, err
+}
+
+// checkActivityName returns ErrActivityExists wrapped into DuplicateError if
+// another not deleted activity of the user has the same name regardless of case
+// and whitespace, and the number of active activities otherwise.
+func checkActivityName(ctx context.Context, tx *sql.Tx, userID int64, activityID uint64, name string) (active int, _ error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(name, ""u), COALESCE(archived, false)
+		FROM marathons
+		WHERE user_id=$1 AND deleted_ts IS NULL;
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	key := validation.Key(name)
+	for rows.Next() {
+		var (
+			activity Activity
+			archived bool
+		)
+		if err := rows.Scan(&activity.ID, &activity.Name, &archived); err != nil {
+			return 0, err
+		}
+		if activity.ID != activityID && validation.Key(activity.Name) == key {
+			return 0, &DuplicateError{Existing: activity, Archived: archived}
+		}
+		if !archived {
+			active++
+		}
+	}
+	return active, rows.Err()
+}
+
+// UndoDeleteUserActivity restores the activity deleted no longer than the undo window ago,
+// unless another activity took its name meanwhile or the restored one exceeds MAX_ACTIVITIES.
+func (s *storage) UndoDeleteUserActivity(ctx context.Context, userID int64, activityID uint64) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		var (
+			name     string
+			archived bool
+		)
+		row := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(name, ""u), COALESCE(archived, false)
+			FROM marathons
+			WHERE user_id=$1 AND id=$2 AND deleted_ts>=$3;
+		`, userID, activityID, time.Now().UTC().Add(-time.Duration(env.UndoWindowMinutes())*time.Minute))
+		if err := row.Scan(&name, &archived); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("activity %d of user %d was not deleted recently: %w", activityID, userID, ErrNothingToUndo)
+			}
+			return err
+		}
+		active, err := checkActivityName(ctx, tx, userID, activityID, name)
+		if err != nil {
+			return err
+		}
+		if !archived && active >= env.MaxActivities() {
+			return fmt.Errorf("user %d has %d activities: %w", userID, active, ErrTooManyActivities)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE marathons SET deleted_ts=NULL
+			WHERE user_id=$1 AND id=$2;
+			`, userID, activityID,
+		)
+		return err
+	})
+}
+
+// PurgeDeletedActivities drops activities deleted longer than the undo window ago
+// together with their posts.
+func (s *storage) PurgeDeletedActivities(ctx context.Context) error {
+	return retry.DoTx(ctx, s.db, func(ctx context.Context, tx *sql.Tx) error {
+		deadline := time.Now().UTC().Add(-time.Duration(env.UndoWindowMinutes()) * time.Minute)
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM marathon_posts ON
+			SELECT p.user_id AS user_id, p.activity_id AS activity_id, p.ts AS ts
+			FROM marathon_posts AS p
+			JOIN marathons AS m ON p.user_id=m.user_id AND p.activity_id=m.id
+			WHERE m.deleted_ts<$1;
+			`, deadline,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM marathons
+			WHERE deleted_ts<$1;
+			`, deadline,
+		)
+		return err
+	})
+}
@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/logging"
+)
+
+// commands are commands and callbacks of the bot. Anything else is reported
+// as "other" to keep the number of metric series bounded.
+var commands = map[string]bool{
+	"/start": true, "/stop": true, "/cancel": true, "/language": true,
+	"/post": true, "/stats": true, "/rotate": true, "/undo": true, "/undo_post": true,
+	"/add": true, "/import": true, "/rename": true, "/target": true, "/remove": true, "/undo_remove": true,
+	"/archive": true, "/restore": true, "/pause": true, "/skip": true, "/remind_post": true,
+	"/snooze": true, "/snooze_at": true, "/snooze_until": true,
+	"/set_rotate_hour": true, "/set_remind_hour": true, "/set_firm_remind": true, "/set_last_call": true,
+	"/digest": true, "/digest_weekly": true, "/digest_monthly": true,
+}
+
+// command is the command of the update for metrics: the first word of a command
+// or of callback data, "text" for other messages.
+func command(update *models.Update) string {
+	var text string
+	switch {
+	case update.CallbackQuery != nil:
+		text = update.CallbackQuery.Data
+	case update.Message != nil:
+		text = update.Message.Text
+		if !strings.HasPrefix(text, "/") {
+			return "text"
+		}
+	default:
+		return "other"
+	}
+	name, _, _ := strings.Cut(text, " ")
+	if !commands[name] {
+		return "other"
+	}
+	return name
+}
+
+// correlation are log attributes of the update.
+func correlation(update *models.Update, command string) []any {
+	args := []any{logging.UpdateID, update.ID, logging.Command, command}
+	switch {
+	case update.Message != nil:
+		args = append(args, logging.ChatID, update.Message.Chat.ID)
+		if update.Message.From != nil {
+			args = append(args, logging.UserID, update.Message.From.ID)
+		}
+	case update.CallbackQuery != nil:
+		args = append(args, logging.UserID, update.CallbackQuery.Sender.ID)
+		if update.CallbackQuery.Message != nil {
+			args = append(args, logging.ChatID, update.CallbackQuery.Message.Chat.ID)
+		}
+	}
+	return args
+}
@@ -0,0 +1,223 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/conversation"
+	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/i18n"
+	"marathon_procrastination_bot/internal/storage"
+)
+
+// startConversation asks the user for input and remembers the flow
+// which must handle the next text message of the user.
+func (a *Agent) startConversation(ctx context.Context, b *bot.Bot, lang i18n.Lang, chatID int64, userID int64,
+	flow conversation.Flow, arg string, prompt string,
+) (*models.Message, error) {
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   prompt + i18n.T(lang, i18n.CancelHint),
+		ReplyMarkup: &models.ForceReply{
+			ForceReply: true,
+			Selective:  true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	state := conversation.New(flow, arg, time.Duration(env.ConversationTimeoutMinutes())*time.Minute)
+	state.PromptID = msg.ID
+	return msg, a.storage.SetUserConversation(ctx, userID, state)
+}
+
+// finishConversation forgets the flow of the user and deletes its prompt.
+func (a *Agent) finishConversation(ctx context.Context, b *bot.Bot, chatID int64, userID int64, state conversation.State) error {
+	if env.DeletePrompts() && state.PromptID != 0 {
+		_ = dropPrompt(ctx, b, chatID, state.PromptID)
+	}
+	return a.storage.DeleteUserConversation(ctx, userID)
+}
+
+// abortConversation finishes the flow which cannot go on, e.g. when its
+// activity is gone, and tells the user why.
+func (a *Agent) abortConversation(ctx context.Context, b *bot.Bot, lang i18n.Lang, msg *models.Message,
+	state conversation.State, cause error,
+) (*models.Message, error) {
+	if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+		return nil, err
+	}
+	return b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:           msg.Chat.ID,
+		Text:             i18n.T(lang, i18n.ConversationAborted, explain(ctx, lang, cause)),
+		ReplyToMessageID: msg.ID,
+	})
+}
+
+// converse handles a plain text message according to the persisted flow of the user.
+func (a *Agent) converse(ctx context.Context, b *bot.Bot, lang i18n.Lang, msg *models.Message) (*models.Message, error) {
+	state, has, err := a.storage.UserConversation(ctx, msg.From.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	if state.Expired(time.Now().UTC()) {
+		if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+			return nil, err
+		}
+		return b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:           msg.Chat.ID,
+			Text:             i18n.T(lang, i18n.ConversationExpired),
+			ReplyToMessageID: msg.ID,
+		})
+	}
+	text := strings.TrimSpace(msg.Text)
+	switch state.Flow {
+	case conversation.Create:
+		if err := a.storage.NewUserActivity(ctx, msg.From.ID, text); err != nil {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: msg.Chat.ID,
+				Text: i18n.T(lang, i18n.CreateFailed,
+					text,
+					msg.From.Username,
+					explain(ctx, lang, err),
+				),
+				ReplyToMessageID: msg.ID,
+			})
+		}
+		committed(ctx)
+		if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+			return nil, err
+		}
+		return b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text: i18n.T(lang, i18n.Created,
+				msg.From.Username,
+				text,
+			),
+			ReplyToMessageID: msg.ID,
+		})
+	case conversation.Rename:
+		activity, err := a.conversationActivity(ctx, msg.From.ID, state)
+		if err != nil {
+			return a.abortConversation(ctx, b, lang, msg, state, err)
+		}
+		if err := a.storage.RenameUserActivity(ctx, msg.From.ID, activity.ID, text); err != nil {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: msg.Chat.ID,
+				Text: i18n.T(lang, i18n.RenameFailed,
+					activity.Name,
+					msg.From.Username,
+					explain(ctx, lang, err),
+				),
+				ReplyToMessageID: msg.ID,
+			})
+		}
+		committed(ctx)
+		if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+			return nil, err
+		}
+		return b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:           msg.Chat.ID,
+			Text:             i18n.T(lang, i18n.Renamed, activity.Name, text),
+			ReplyToMessageID: msg.ID,
+		})
+	case conversation.Target:
+		activity, err := a.conversationActivity(ctx, msg.From.ID, state)
+		if err != nil {
+			return a.abortConversation(ctx, b, lang, msg, state, err)
+		}
+		target, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           msg.Chat.ID,
+				Text:             i18n.T(lang, i18n.InvalidTarget),
+				ReplyToMessageID: msg.ID,
+			})
+		}
+		if err := a.storage.SetUserActivityTarget(ctx, msg.From.ID, activity.ID, target); err != nil {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: msg.Chat.ID,
+				Text: i18n.T(lang, i18n.TargetFailed,
+					activity.Name,
+					msg.From.Username,
+					explain(ctx, lang, err),
+				),
+				ReplyToMessageID: msg.ID,
+			})
+		}
+		committed(ctx)
+		if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+			return nil, err
+		}
+		if target == 0 {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           msg.Chat.ID,
+				Text:             i18n.T(lang, i18n.TargetReset, activity.Name),
+				ReplyToMessageID: msg.ID,
+			})
+		}
+		return b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text: i18n.T(lang, i18n.TargetSet,
+				activity.Name,
+				target, i18n.N(lang, i18n.Days, int(target)),
+			),
+			ReplyToMessageID: msg.ID,
+		})
+	case conversation.Import:
+		var builder strings.Builder
+		for _, line := range strings.Split(text, "\n") {
+			activity := strings.TrimSpace(line)
+			if activity == "" {
+				continue
+			}
+			if err := a.storage.NewUserActivity(ctx, msg.From.ID, activity); err != nil {
+				_, _ = fmt.Fprintf(&builder, "\n- %q ❌ %s", activity, explain(ctx, lang, err))
+				continue
+			}
+			committed(ctx)
+			_, _ = fmt.Fprintf(&builder, "\n- %q ✅", activity)
+		}
+		if err := a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state); err != nil {
+			return nil, err
+		}
+		return b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:           msg.Chat.ID,
+			Text:             i18n.T(lang, i18n.ImportTitle) + builder.String(),
+			ReplyToMessageID: msg.ID,
+		})
+	}
+	return nil, a.finishConversation(ctx, b, msg.Chat.ID, msg.From.ID, state)
+}
+
+// conversationActivity resolves the activity whose ID is the argument of the flow.
+func (a *Agent) conversationActivity(ctx context.Context, userID int64, state conversation.State) (activity storage.Activity, _ error) {
+	id, err := strconv.ParseUint(state.Arg, 10, 64)
+	if err != nil {
+		return activity, fmt.Errorf("invalid activity id %q: %w", state.Arg, storage.ErrActivityNotFound)
+	}
+	return a.storage.UserActivity(ctx, userID, id)
+}
+
+// activitiesKeyboard is a keyboard to choose one of the activities for the command.
+// Callback data references activities by ID to fit into 64 bytes whatever the names are.
+func activitiesKeyboard(command string, activities []storage.Activity) *models.InlineKeyboardMarkup {
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: make([][]models.InlineKeyboardButton, 0, len(activities)),
+	}
+	for _, activity := range activities {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: activity.Name, CallbackData: command + " " + strconv.FormatUint(activity.ID, 10)},
+		})
+	}
+	return keyboard
+}
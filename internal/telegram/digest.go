@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/digest"
+	"marathon_procrastination_bot/internal/i18n"
+	"marathon_procrastination_bot/internal/outbox"
+)
+
+// DigestUser sends weekly and monthly digests of the user, if they are due.
+func (a *Agent) DigestUser(ctx context.Context, userID int64) error {
+	settings, err := a.storage.UserDigestSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	due := settings.Due(time.Now().UTC())
+	if len(due) == 0 {
+		return nil
+	}
+	chatID, err := a.storage.UserRegistrationChatID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	activities, err := a.storage.UserActivities(ctx, userID)
+	if err != nil {
+		return err
+	}
+	lang := a.userLanguage(ctx, userID)
+	names := make([]string, len(activities))
+	for i, activity := range activities {
+		names[i] = activity.Name
+	}
+	for _, kind := range []digest.Kind{digest.Weekly, digest.Monthly} {
+		period, has := due[kind]
+		if !has {
+			continue
+		}
+		posts, err := a.storage.UserPosts(ctx, userID, period.PrevFrom, period.To)
+		if err != nil {
+			return err
+		}
+		err = a.notify(ctx, outbox.Message{
+			UserID: userID,
+			ChatID: chatID,
+			Text:   formatDigest(lang, kind, digest.Summarize(settings.RotateHour, period, names, posts)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := a.storage.MarkUserDigestSent(ctx, userID, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatDigest(lang i18n.Lang, kind digest.Kind, summary digest.Summary) string {
+	title, prev := i18n.T(lang, i18n.WeeklyTitle), i18n.T(lang, i18n.PrevWeek)
+	if kind == digest.Monthly {
+		title, prev = i18n.T(lang, i18n.MonthlyTitle), i18n.T(lang, i18n.PrevMonth)
+	}
+	var builder strings.Builder
+	builder.WriteString(i18n.T(lang, i18n.DigestTitle,
+		title,
+		summary.Period.From.Format("02.01"),
+		summary.Period.To.AddDate(0, 0, -1).Format("02.01"),
+	))
+	for _, activity := range summary.Activities {
+		builder.WriteString(i18n.T(lang, i18n.DigestLine,
+			activity.Name,
+			activity.Days,
+			summary.Days, i18n.N(lang, i18n.DaysGenitive, summary.Days),
+			prev,
+			activity.PrevDays,
+			activity.StreakBefore,
+			activity.StreakAfter,
+		))
+	}
+	if summary.HasBestWeekday {
+		builder.WriteString(i18n.T(lang, i18n.DigestBestWeekday, i18n.T(lang, i18n.Weekdays[summary.BestWeekday])))
+	}
+	builder.WriteString(i18n.T(lang, i18n.DigestTotal,
+		summary.Total,
+		prev,
+		summary.PrevTotal,
+		summary.Total-summary.PrevTotal,
+	))
+	return builder.String()
+}
+
+func digestKeyboard(lang i18n.Lang) *models.InlineKeyboardMarkup {
+	row := make([]models.InlineKeyboardButton, 0, 7)
+	for _, wd := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         i18n.T(lang, i18n.ShortWeekdays[wd]),
+			CallbackData: fmt.Sprintf("/digest_weekly %d", wd),
+		})
+	}
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			row,
+			{
+				{Text: i18n.T(lang, i18n.WeeklyOffButton), CallbackData: fmt.Sprintf("/digest_weekly %d", digest.Off)},
+			},
+			{
+				{Text: i18n.T(lang, i18n.MonthlyOnButton), CallbackData: "/digest_monthly on"},
+				{Text: i18n.T(lang, i18n.MonthlyOffButton), CallbackData: "/digest_monthly off"},
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+
+	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/i18n"
+	"marathon_procrastination_bot/internal/storage"
+	"marathon_procrastination_bot/internal/validation"
+)
+
+// explain turns the error into a message which is safe to show to the user.
+// Domain errors of the storage get friendly messages, unexpected ones are
+// logged with a correlation ID, and only the ID is shown to the user.
+func explain(ctx context.Context, lang i18n.Lang, err error) string {
+	var duplicate *storage.DuplicateError
+	switch {
+	case errors.As(err, &duplicate) && duplicate.Archived:
+		return i18n.T(lang, i18n.ActivityArchivedExists, duplicate.Existing.Name)
+	case errors.As(err, &duplicate):
+		return i18n.T(lang, i18n.ActivityDuplicate, duplicate.Existing.Name)
+	case errors.Is(err, storage.ErrUserNotFound):
+		return i18n.T(lang, i18n.UserNotFound)
+	case errors.Is(err, storage.ErrActivityNotFound):
+		return i18n.T(lang, i18n.ActivityNotFound)
+	case errors.Is(err, storage.ErrActivityExists):
+		return i18n.T(lang, i18n.ActivityExists)
+	case errors.Is(err, storage.ErrTooManyActivities):
+		return i18n.T(lang, i18n.TooManyActivities, env.MaxActivities())
+	case errors.Is(err, validation.ErrNameEmpty):
+		return i18n.T(lang, i18n.NameEmpty)
+	case errors.Is(err, validation.ErrNameTooLong):
+		return i18n.T(lang, i18n.NameTooLong, validation.MaxNameLength)
+	case errors.Is(err, validation.ErrNameCommand):
+		return i18n.T(lang, i18n.NameCommand)
+	case errors.Is(err, validation.ErrNameCharset):
+		return i18n.T(lang, i18n.NameCharset)
+	case errors.Is(err, storage.ErrNothingToUndo):
+		return i18n.T(lang, i18n.NothingToUndo)
+	case errors.Is(err, storage.ErrNoFreezesLeft):
+		return i18n.T(lang, i18n.NoFreezesLeft, env.FreezesPerMonth())
+	}
+	id := fmt.Sprintf("%08x", rand.Uint32())
+	slog.ErrorContext(ctx, "unexpected error", "error_id", id, "error", err)
+	return i18n.T(lang, i18n.UnexpectedError, id)
+}
+
+// Permanent reports whether the request for the user is not going to succeed
+// on retry: the user is not registered, blocked the bot or the chat is gone.
+// Bot API errors carry no types, so they are recognized by their text.
+func Permanent(err error) bool {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return true
+	}
+	return Blocked(err)
+}
+
+// Blocked reports whether Telegram refuses to deliver messages to the user:
+// the user blocked the bot, deleted the account or the chat is not found.
+func Blocked(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "statusCode 403") ||
+		strings.Contains(msg, "chat not found") ||
+		strings.Contains(msg, "user is deactivated")
+}
+
+// botAPI reports whether the error is the error of a Bot API call, which the
+// bot library reports with the name of the method.
+func botAPI(err error) bool {
+	return strings.Contains(err.Error(), "for method ")
+}
@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/outbox"
+)
+
+// toast answers the callback query with a short notification. Every callback
+// query must be answered, otherwise the button spinner never stops.
+func toast(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, text string) error {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+		Text:            text,
+	})
+	return err
+}
+
+// alert answers the callback query with a modal notification about a failure.
+func alert(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, text string) error {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+		Text:            text,
+		ShowAlert:       true,
+	})
+	return err
+}
+
+// replace edits the message with an inline keyboard in place instead of sending
+// a new one. The keyboard is removed if markup is nil.
+func replace(ctx context.Context, b *bot.Bot, msg *models.Message, text string, markup *models.InlineKeyboardMarkup) (*models.Message, error) {
+	params := &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+	}
+	if markup != nil {
+		params.ReplyMarkup = markup
+	}
+	return b.EditMessageText(ctx, params)
+}
+
+// dropPrompt deletes a transient prompt message which is not needed anymore.
+func dropPrompt(ctx context.Context, b *bot.Bot, chatID int64, messageID int) error {
+	_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+	})
+	return err
+}
+
+// notify queues the message which the bot sends on its own, not as a reply.
+// Queued messages are delivered by the outbox within rate limits of the Bot API.
+// Users who blocked the bot are deactivated on delivery by the OnBlocked hook of
+// the outbox, not here.
+func (a *Agent) notify(ctx context.Context, message outbox.Message) error {
+	return a.outbox.Enqueue(ctx, message)
+}
+
+// send delivers the queued message to Telegram.
+func (a *Agent) send(ctx context.Context, message outbox.Message) error {
+	params := &bot.SendMessageParams{
+		ChatID: message.ChatID,
+		Text:   message.Text,
+	}
+	if message.Keyboard != nil {
+		params.ReplyMarkup = message.Keyboard
+	}
+	_, err := a.bot.SendMessage(ctx, params)
+	return err
+}
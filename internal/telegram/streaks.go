@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"strings"
+
+	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/i18n"
+	"marathon_procrastination_bot/internal/outbox"
+	"marathon_procrastination_bot/internal/storage"
+)
+
+// StreakMessages builds messages about extended and broken streaks after the
+// stats rotation. Everything needed is in the rotations, so no queries are made.
+func (a *Agent) StreakMessages(rotations []storage.Rotation) []outbox.Message {
+	if !env.StreakMessages() {
+		return nil
+	}
+	messages := make([]outbox.Message, 0, len(rotations))
+	for _, rotation := range rotations {
+		if rotation.Inactive {
+			continue
+		}
+		lang, _ := i18n.Parse(rotation.Language)
+		var builder strings.Builder
+		for _, streak := range rotation.Streaks {
+			switch {
+			case streak.Extended():
+				builder.WriteString(i18n.T(lang, i18n.StreakExtended,
+					streak.Activity.Name,
+					streak.After, i18n.N(lang, i18n.Days, int(streak.After)),
+				))
+			case streak.Broken():
+				builder.WriteString(i18n.T(lang, i18n.StreakBroken,
+					streak.Activity.Name,
+					streak.Before, i18n.N(lang, i18n.DaysGenitive, int(streak.Before)),
+				))
+			}
+		}
+		if builder.Len() == 0 {
+			continue
+		}
+		messages = append(messages, outbox.Message{
+			UserID: rotation.UserID,
+			ChatID: rotation.ChatID,
+			Text:   i18n.T(lang, i18n.StreaksTitle) + builder.String(),
+		})
+	}
+	return messages
+}
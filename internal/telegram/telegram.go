@@ -2,37 +2,32 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"marathon_procrastination_bot/internal/conversation"
+	"marathon_procrastination_bot/internal/digest"
 	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/i18n"
+	"marathon_procrastination_bot/internal/logging"
+	"marathon_procrastination_bot/internal/metrics"
+	"marathon_procrastination_bot/internal/outbox"
+	"marathon_procrastination_bot/internal/reminder"
+	"marathon_procrastination_bot/internal/storage"
+	"marathon_procrastination_bot/internal/tracing"
 )
 
-const welcome = `
-
-⚡️⚡️⚡️Правила марафона ⚡️⚡️⚡️
-
-1. Заводишь себе 🎯🎯🎯Новый марафон🎯🎯🎯 через /post 
-Это типа привычку или обязанность, которую хочешь тренить. 
-
-2. и каждый день выполняешь то, что задумал.  
-При этом отписываешься в ботике, что выполнил.
-
-3. Ботик считает непрерывное количество дней - это твоя ачивка 💪 (как у зависимых медальки 'дней в завязке').
-
-4. Если пропускаешь день - счетчик непрерывного количества дней сбрасывается в НОЛЬ. 
-
-Так что не пропускай 🤬
-
-⭐️⭐️⭐️Удачи тебе, друг мой! ⭐️⭐️⭐️
-`
-
 func mustToken() string {
 	t, has := os.LookupEnv(env.TELEGRAM_TOKEN)
 	if !has {
@@ -42,23 +37,55 @@ func mustToken() string {
 }
 
 type Storage interface {
-	AddUser(ctx context.Context, userID int64, chatID int64) error
+	outbox.Storage
+	AddUser(ctx context.Context, userID int64, chatID int64, lang string) error
 	RemoveUser(ctx context.Context, userID int64) error
-	NewUserActivity(ctx context.Context, userID int64, activity string) error
-	DeleteUserActivity(ctx context.Context, userID int64, activity string) error
-	PostUserActivity(ctx context.Context, userID int64, activity string) error
-	UserActivities(ctx context.Context, userID int64) (activities []string, _ error)
+	DeactivateUser(ctx context.Context, userID int64) error
+	ActivateUser(ctx context.Context, userID int64) (activated bool, _ error)
+	ClaimUpdate(ctx context.Context, updateID int64) (claimed bool, _ error)
+	ReleaseUpdate(ctx context.Context, updateID int64) error
+	UserLanguage(ctx context.Context, userID int64) (lang string, _ error)
+	SetUserLanguage(ctx context.Context, userID int64, lang string) error
+	NewUserActivity(ctx context.Context, userID int64, name string) error
+	DeleteUserActivity(ctx context.Context, userID int64, activityID uint64) error
+	PostUserActivity(ctx context.Context, userID int64, activityID uint64) error
+	UndoLastUserPost(ctx context.Context, userID int64) (activity storage.Activity, _ error)
+	UserActivities(ctx context.Context, userID int64) (activities []storage.Activity, _ error)
+	UserActivity(ctx context.Context, userID int64, activityID uint64) (activity storage.Activity, _ error)
+	UserOverview(ctx context.Context, userID int64) (overview storage.Overview, _ error)
+	PauseUserActivity(ctx context.Context, userID int64, activityID uint64) error
+	FreezeUserActivities(ctx context.Context, userID int64) (left uint32, _ error)
+	SnoozeUser(ctx context.Context, userID int64, until time.Time) error
+	DeleteUserSnooze(ctx context.Context, userID int64) error
 	UserRegistrationChatID(ctx context.Context, userID int64) (chatID int64, _ error)
-	UserStats(ctx context.Context, userID int64, activity string) (total uint64, current uint64, err error)
-	UpdateUserActivityLastNotificated(ctx context.Context, userID int64, activities ...string) error
-	RotateUserStats(ctx context.Context, userID int64) error
+	UpdateUserActivityLastNotificated(ctx context.Context, userID int64, activityIDs ...uint64) error
+	RotateUserStats(ctx context.Context, userID int64, messages storage.StreakMessages) error
 	SetUserRotateHour(ctx context.Context, userID int64, hour int32) error
 	UsersForRotate(ctx context.Context, hour int32) (ids []int64, err error)
+	UserReminderPolicy(ctx context.Context, userID int64) (policy reminder.Policy, state reminder.State, _ error)
+	SetUserRemindHour(ctx context.Context, userID int64, hour int32) error
+	SetUserFirmRemindHours(ctx context.Context, userID int64, hours int32) error
+	SetUserLastCallRemind(ctx context.Context, userID int64, enabled bool) error
+	MarkUserReminded(ctx context.Context, userID int64, step reminder.Step) error
+	UserDigestSettings(ctx context.Context, userID int64) (settings digest.Settings, _ error)
+	UserPosts(ctx context.Context, userID int64, from, to time.Time) (posts []digest.Post, _ error)
+	SetUserWeeklyDigest(ctx context.Context, userID int64, weekday int32, hour int32) error
+	SetUserMonthlyDigest(ctx context.Context, userID int64, enabled bool) error
+	MarkUserDigestSent(ctx context.Context, userID int64, kind digest.Kind) error
+	UserConversation(ctx context.Context, userID int64) (state conversation.State, has bool, _ error)
+	SetUserConversation(ctx context.Context, userID int64, state conversation.State) error
+	DeleteUserConversation(ctx context.Context, userID int64) error
+	RenameUserActivity(ctx context.Context, userID int64, activityID uint64, name string) error
+	SetUserActivityTarget(ctx context.Context, userID int64, activityID uint64, target uint64) error
+	SetUserActivityArchived(ctx context.Context, userID int64, activityID uint64, archived bool) error
+	UserArchivedActivities(ctx context.Context, userID int64) (activities []storage.Activity, _ error)
+	UndoDeleteUserActivity(ctx context.Context, userID int64, activityID uint64) error
 }
 
 type Agent struct {
 	bot     *bot.Bot
 	storage Storage
+	outbox  *outbox.Queue
 }
 
 func New(s Storage) (_ *Agent, err error) {
@@ -67,16 +94,23 @@ func New(s Storage) (_ *Agent, err error) {
 	}
 	agent.bot, err = bot.New(mustToken(),
 		bot.WithSkipGetMe(),
+		bot.WithHTTPClient(pollTimeout, tracedClient{&http.Client{Timeout: pollTimeout}}),
 		bot.WithDefaultHandler(func(ctx context.Context, bot *bot.Bot, update *models.Update) {
-			_, err := agent.Handle(ctx, bot, update)
-			if err != nil {
-				log.Println(err)
-			}
+			// failures are logged by Handle
+			_, _ = agent.Handle(ctx, bot, update)
 		}),
 	)
 	if err != nil {
 		return nil, err
 	}
+	agent.outbox = outbox.New(s, agent.send)
+	agent.outbox.Blocked = Blocked
+	agent.outbox.OnBlocked = func(ctx context.Context, userID int64) {
+		ctx = logging.With(ctx, logging.UserID, userID)
+		if err := s.DeactivateUser(ctx, userID); err != nil {
+			slog.ErrorContext(ctx, "deactivate user", "error", err)
+		}
+	}
 	return agent, nil
 }
 
@@ -84,51 +118,195 @@ func (a *Agent) Bot() *bot.Bot {
 	return a.bot
 }
 
+// Outbox is the queue of messages which the bot sends on its own, not in reply to updates.
+func (a *Agent) Outbox() *outbox.Queue {
+	return a.outbox
+}
+
 func (a *Agent) Storage() Storage {
 	return a.storage
 }
 
-func (a *Agent) PingUser(ctx context.Context, userID int64) error {
-	activities, err := a.storage.UserActivities(ctx, userID)
+// language returns the language chosen by the user with /language
+// or detected from the Telegram client of the user.
+func (a *Agent) language(ctx context.Context, user *models.User) i18n.Lang {
+	if lang, err := a.storage.UserLanguage(ctx, user.ID); err == nil {
+		if lang, ok := i18n.Parse(lang); ok {
+			return lang
+		}
+	}
+	lang, _ := i18n.Parse(user.LanguageCode)
+	return lang
+}
+
+// userLanguage returns the stored language of the user for messages
+// which are not replies to updates.
+func (a *Agent) userLanguage(ctx context.Context, userID int64) i18n.Lang {
+	lang, err := a.storage.UserLanguage(ctx, userID)
 	if err != nil {
-		return err
+		return i18n.Default
 	}
-	chatID, err := a.storage.UserRegistrationChatID(ctx, userID)
+	l, _ := i18n.Parse(lang)
+	return l
+}
+
+func (a *Agent) PingUser(ctx context.Context, userID int64) error {
+	overview, err := a.storage.UserOverview(ctx, userID)
 	if err != nil {
 		return err
 	}
-	var builder strings.Builder
-	for _, activity := range activities {
-		total, current, err := a.storage.UserStats(ctx, userID, activity)
-		if err != nil {
-			return err
-		}
-		if current == 0 {
-			_, _ = fmt.Fprintf(&builder, "\n- %q (дней непрерывно: %d 💪, за последние сутки: %d 🤬)", activity, total, current)
-		}
+	pending := overview.Pending()
+	if len(pending) == 0 {
+		return nil
 	}
-	_, err = a.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: chatID,
-		Text: "Нежно напоминаю тебе про твои марафоны:\n" + builder.String() + "\n\n" +
-			"Используй команду /post - чтобы записать участие в марафоне",
+	lang, _ := i18n.Parse(overview.Language)
+	err = a.notify(ctx, outbox.Message{
+		UserID:   userID,
+		ChatID:   overview.ChatID,
+		Text:     i18n.T(lang, i18n.GentleReminder, pendingList(lang, pending)),
+		Keyboard: reminderKeyboard(lang, pending),
 	})
 	if err != nil {
 		return err
 	}
-	if err := a.storage.UpdateUserActivityLastNotificated(ctx, userID, activities...); err != nil {
+	ids := make([]uint64, len(overview.Marathons))
+	for i, marathon := range overview.Marathons {
+		ids[i] = marathon.ID
+	}
+	if err := a.storage.UpdateUserActivityLastNotificated(ctx, userID, ids...); err != nil {
 		return err
 	}
 	return err
 }
 
+// WakeUser pings the user whose snooze has expired. The snooze is deleted
+// only after the ping is queued, so a failed wake is retried by the next run.
+func (a *Agent) WakeUser(ctx context.Context, userID int64) error {
+	if err := a.PingUser(ctx, userID); err != nil {
+		return err
+	}
+	return a.storage.DeleteUserSnooze(ctx, userID)
+}
+
+// RemindUser sends the next step of the escalating reminder policy, if it is due.
+func (a *Agent) RemindUser(ctx context.Context, userID int64) error {
+	policy, state, err := a.storage.UserReminderPolicy(ctx, userID)
+	if err != nil {
+		return err
+	}
+	step := policy.Due(state, time.Now().UTC())
+	if step == reminder.None {
+		return nil
+	}
+	overview, err := a.storage.UserOverview(ctx, userID)
+	if err != nil {
+		return err
+	}
+	pending := overview.Pending()
+	if len(pending) == 0 {
+		return nil
+	}
+	lang, _ := i18n.Parse(overview.Language)
+	list := pendingList(lang, pending)
+	message := outbox.Message{
+		UserID:   userID,
+		ChatID:   overview.ChatID,
+		Keyboard: reminderKeyboard(lang, pending),
+	}
+	switch step {
+	case reminder.Gentle:
+		message.Text = i18n.T(lang, i18n.GentleReminder, list)
+	case reminder.Firm:
+		message.Text = i18n.T(lang, i18n.FirmReminder,
+			policy.FirmHours, i18n.N(lang, i18n.HoursGenitive, int(policy.FirmHours)),
+			list,
+		)
+	case reminder.LastCall:
+		minutes := int(reminder.LastCallBefore.Minutes())
+		message.Text = i18n.T(lang, i18n.LastCallReminder,
+			minutes, i18n.N(lang, i18n.Minutes, minutes),
+			list,
+		)
+	}
+	if err = a.notify(ctx, message); err != nil {
+		return err
+	}
+	return a.storage.MarkUserReminded(ctx, userID, step)
+}
+
+// pendingList is the human-readable list of pending marathons with streaks.
+func pendingList(lang i18n.Lang, pending []storage.Marathon) string {
+	var builder strings.Builder
+	for _, marathon := range pending {
+		builder.WriteString(i18n.T(lang, i18n.PendingLine, marathon.Name, marathon.Total))
+	}
+	return builder.String()
+}
+
+// refreshReminder edits the reminder message in place after a tap on its keyboard.
+// After a post the keyboard offers to undo it.
+func (a *Agent) refreshReminder(ctx context.Context, b *bot.Bot, lang i18n.Lang, msg *models.Message, userID int64, status string, posted bool) (*models.Message, error) {
+	overview, err := a.storage.UserOverview(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		pending  = overview.Pending()
+		text     = i18n.T(lang, i18n.AllDone, status)
+		keyboard = &models.InlineKeyboardMarkup{}
+	)
+	if len(pending) > 0 {
+		text = i18n.T(lang, i18n.StillPending, status, pendingList(lang, pending))
+		keyboard = reminderKeyboard(lang, pending)
+	}
+	if posted {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: i18n.T(lang, i18n.UndoPostButton), CallbackData: "/undo_post"},
+		})
+	}
+	if len(keyboard.InlineKeyboard) == 0 {
+		return replace(ctx, b, msg, text, nil)
+	}
+	return replace(ctx, b, msg, text, keyboard)
+}
+
+// callbackActivity resolves the activity referenced by ID in the callback data.
+func (a *Agent) callbackActivity(ctx context.Context, userID int64, data string) (activity storage.Activity, _ error) {
+	id, err := strconv.ParseUint(data[strings.Index(data, " ")+1:], 10, 64)
+	if err != nil {
+		return activity, fmt.Errorf("invalid activity id in %q: %w", data, storage.ErrActivityNotFound)
+	}
+	return a.storage.UserActivity(ctx, userID, id)
+}
+
+// reactivate brings the user who blocked the bot earlier back to notifications
+// as soon as the user messages the bot or taps a button again.
+func (a *Agent) reactivate(ctx context.Context, update *models.Update) {
+	var userID int64
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		userID = update.Message.From.ID
+	case update.CallbackQuery != nil:
+		userID = update.CallbackQuery.Sender.ID
+	default:
+		return
+	}
+	if activated, err := a.storage.ActivateUser(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "activate user", "error", err)
+	} else if activated {
+		slog.InfoContext(ctx, "user is active again")
+	}
+}
+
 func (a *Agent) Welcome(ctx context.Context, userID int64) error {
 	chatID, err := a.storage.UserRegistrationChatID(ctx, userID)
 	if err != nil {
 		return err
 	}
-	_, err = a.bot.SendMessage(ctx, &bot.SendMessageParams{
+	err = a.notify(ctx, outbox.Message{
+		UserID: userID,
 		ChatID: chatID,
-		Text:   welcome,
+		Text:   i18n.T(a.userLanguage(ctx, userID), i18n.Welcome),
 	})
 	if err != nil {
 		return err
@@ -136,24 +314,107 @@ func (a *Agent) Welcome(ctx context.Context, userID int64) error {
 	return err
 }
 
-func (a *Agent) Handle(ctx context.Context, b *bot.Bot, update *models.Update) (*models.Message, error) {
-	const enterActivityName = "В ОТВЕТНОМ СООБЩЕНИИ (кнопка меню Ответить/Reply) напиши название марафона"
+// Handle processes the update once: redeliveries of the update, which Telegram sends
+// when the webhook responds slowly, are skipped. If processing fails, the update
+// may be processed again on redelivery, unless the change made by the update is
+// committed or only the Bot API call failed: processing it again would apply the
+// change twice, e.g. count a post twice, so the error is only logged.
+func (a *Agent) Handle(ctx context.Context, b *bot.Bot, update *models.Update) (_ *models.Message, err error) {
+	command, start := command(update), time.Now()
+	args := correlation(update, command)
+	ctx, span := tracing.Start(ctx, "telegram.Handle",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(tracing.Attributes(args...)...),
+	)
+	ctx = logging.With(ctx, args...)
+	progress := &handling{}
+	ctx = context.WithValue(ctx, handlingKey{}, progress)
+	result := "ok"
+	defer func() {
+		if err != nil {
+			result = "error"
+			slog.ErrorContext(ctx, "handle update", "error", err)
+		}
+		span.SetAttributes(attribute.String("result", result))
+		tracing.End(span, err)
+		metrics.Updates.WithLabelValues(command, result).Inc()
+		metrics.UpdateDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+		slog.DebugContext(ctx, "update handled", "result", result, "duration", time.Since(start))
+	}()
+	claimed, err := a.storage.ClaimUpdate(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		slog.InfoContext(ctx, "update is already processed")
+		result = "duplicate"
+		return nil, nil
+	}
+	msg, err := a.handle(ctx, b, update)
+	if err != nil && (progress.committed || botAPI(err)) {
+		slog.WarnContext(ctx, "reply to update", "error", err)
+		result = "reply_failed"
+		return msg, nil
+	}
+	if err != nil {
+		if err := a.storage.ReleaseUpdate(ctx, update.ID); err != nil {
+			slog.ErrorContext(ctx, "release update", "error", err)
+		}
+	}
+	return msg, err
+}
+
+// handling is the progress of Handle on the update.
+type handling struct {
+	// committed is set once the change made by the update is committed,
+	// later failures only affect the reply.
+	committed bool
+}
+
+type handlingKey struct{}
+
+// committed marks the change made by the update as committed.
+func committed(ctx context.Context) {
+	if progress, ok := ctx.Value(handlingKey{}).(*handling); ok {
+		progress.committed = true
+	}
+}
+
+func (a *Agent) handle(ctx context.Context, b *bot.Bot, update *models.Update) (*models.Message, error) {
+	a.reactivate(ctx, update)
 	if update.Message != nil {
+		lang := a.language(ctx, update.Message.From)
+		if update.Message.Text == "/cancel" {
+			state, has, err := a.storage.UserConversation(ctx, update.Message.From.ID)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				if err := a.finishConversation(ctx, b, update.Message.Chat.ID, update.Message.From.ID, state); err != nil {
+					return nil, err
+				}
+			}
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           update.Message.Chat.ID,
+				Text:             i18n.T(lang, i18n.Cancelled),
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
 		if update.Message.Text == "/start" {
-			err := a.storage.AddUser(ctx, update.Message.From.ID, update.Message.Chat.ID)
+			err := a.storage.AddUser(ctx, update.Message.From.ID, update.Message.Chat.ID, string(lang))
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось сохранить пользователя @%s: %v",
+					Text: i18n.T(lang, i18n.UserAddFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
 			msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: update.Message.Chat.ID,
-				Text: fmt.Sprintf("Ок, теперь в нашем марафоне участвует @%s",
+				Text: i18n.T(lang, i18n.UserAdded,
 					update.Message.From.Username,
 				),
 				ReplyToMessageID: update.Message.ID,
@@ -161,9 +422,9 @@ func (a *Agent) Handle(ctx context.Context, b *bot.Bot, update *models.Update) (
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось сохранить пользователя @%s: %v",
+					Text: i18n.T(lang, i18n.UserAddFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
@@ -175,283 +436,709 @@ func (a *Agent) Handle(ctx context.Context, b *bot.Bot, update *models.Update) (
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось удалить пользователя @%s: %v",
+					Text: i18n.T(lang, i18n.UserRemoveFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: update.Message.Chat.ID,
-				Text: fmt.Sprintf("Ок, теперь @%s не участвует в марафонах\n"+
-					"Используй команду /start - чтобы участвовать в марафонах",
+				Text: i18n.T(lang, i18n.UserRemoved,
 					update.Message.From.Username,
 				),
 				ReplyToMessageID: update.Message.ID,
 			})
 		}
+		if update.Message.Text == "/language" {
+			row := make([]models.InlineKeyboardButton, 0, len(i18n.Langs))
+			for _, l := range i18n.Langs {
+				row = append(row, models.InlineKeyboardButton{
+					Text: i18n.Names[l], CallbackData: "/language " + string(l),
+				})
+			}
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   i18n.T(lang, i18n.ChooseLanguage),
+				ReplyMarkup: &models.InlineKeyboardMarkup{
+					InlineKeyboard: [][]models.InlineKeyboardButton{row},
+				},
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
 		if update.Message.Text == "/stats" {
-			activities, err := a.storage.UserActivities(ctx, update.Message.From.ID)
+			overview, err := a.storage.UserOverview(ctx, update.Message.From.ID)
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось получить марафоны пользователя @%s: %v",
+					Text: i18n.T(lang, i18n.ActivitiesFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
 			var builder strings.Builder
-			for _, activity := range activities {
-				total, current, err := a.storage.UserStats(ctx, update.Message.From.ID, activity)
-				if err != nil {
-					return b.SendMessage(ctx, &bot.SendMessageParams{
-						ChatID: update.Message.Chat.ID,
-						Text: fmt.Sprintf("Не удалось получить статистику марафона %q пользователя @%s: %v",
-							activity,
-							update.Message.From.Username,
-							err,
-						),
-						ReplyToMessageID: update.Message.ID,
-					})
+			for _, marathon := range overview.Marathons {
+				builder.WriteString(i18n.T(lang, i18n.StatsLine, marathon.Name, marathon.Total, marathon.Current))
+				if marathon.Target > 0 {
+					builder.WriteString(i18n.T(lang, i18n.StatsTarget, marathon.Total, marathon.Target))
 				}
-				_, _ = fmt.Fprintf(&builder, "\n- %q (дней непрерывно: %d, за последние сутки: %d)", activity, total, current)
 			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID:           update.Message.Chat.ID,
-				Text:             fmt.Sprintf("Статистика марафонов пользователя @%s:", update.Message.From.Username) + builder.String(),
+				Text:             i18n.T(lang, i18n.StatsTitle, update.Message.From.Username) + builder.String(),
 				ReplyToMessageID: update.Message.ID,
 			})
 		}
 		if update.Message.Text == "/rotate" {
-			err := a.storage.RotateUserStats(ctx, update.Message.From.ID)
+			err := a.storage.RotateUserStats(ctx, update.Message.From.ID, a.StreakMessages)
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось обновить статистику пользователя @%s: %v",
+					Text: i18n.T(lang, i18n.RotateFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
+			committed(ctx)
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: update.Message.Chat.ID,
-				Text: fmt.Sprintf("Статистика пользователя @%s обновлена.\n"+
-					"Cтартовал новый день - не забывай про свои марафоны!\n"+
-					"Используй команду /post - чтобы записать участие в марафоне",
+				Text: i18n.T(lang, i18n.Rotated,
 					update.Message.From.Username,
 				),
 				ReplyToMessageID: update.Message.ID,
 			})
 		}
 		if update.Message.Text == "/set_rotate_hour" {
-			rows := make([][]models.InlineKeyboardButton, 0, 4)
-			for i := 0; i < 4; i++ {
-				row := make([]models.InlineKeyboardButton, 0, 6)
-				for j := 0; j < 6; j++ {
-					h := i*6 + j
-					row = append(row, models.InlineKeyboardButton{
-						Text: strconv.Itoa(h), CallbackData: "/set_rotate_hour " + strconv.Itoa(h),
-					})
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           update.Message.Chat.ID,
+				Text:             i18n.T(lang, i18n.ChooseRotateHour),
+				ReplyMarkup:      hoursKeyboard("/set_rotate_hour "),
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
+		if update.Message.Text == "/set_remind_hour" {
+			keyboard := hoursKeyboard("/set_remind_hour ")
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+				{Text: i18n.T(lang, i18n.Off), CallbackData: "/set_remind_hour " + strconv.Itoa(reminder.Off)},
+			})
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           update.Message.Chat.ID,
+				Text:             i18n.T(lang, i18n.ChooseRemindHour),
+				ReplyMarkup:      keyboard,
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
+		if update.Message.Text == "/set_firm_remind" {
+			row := make([]models.InlineKeyboardButton, 0, 7)
+			for h := 0; h <= 6; h++ {
+				text := strconv.Itoa(h)
+				if h == 0 {
+					text = i18n.T(lang, i18n.Off)
 				}
-				rows = append(rows, row)
+				row = append(row, models.InlineKeyboardButton{
+					Text: text, CallbackData: "/set_firm_remind " + strconv.Itoa(h),
+				})
 			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: update.Message.Chat.ID,
-				Text:   "Выбери час ежедневной ротации статистики (UTC)",
+				Text:   i18n.T(lang, i18n.ChooseFirmRemind),
 				ReplyMarkup: &models.InlineKeyboardMarkup{
-					InlineKeyboard: rows,
+					InlineKeyboard: [][]models.InlineKeyboardButton{row},
+				},
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
+		if update.Message.Text == "/digest" {
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           update.Message.Chat.ID,
+				Text:             i18n.T(lang, i18n.ChooseDigest),
+				ReplyMarkup:      digestKeyboard(lang),
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
+		if update.Message.Text == "/set_last_call" {
+			minutes := int(reminder.LastCallBefore.Minutes())
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text: i18n.T(lang, i18n.ChooseLastCall,
+					minutes, i18n.N(lang, i18n.Minutes, minutes),
+				),
+				ReplyMarkup: &models.InlineKeyboardMarkup{
+					InlineKeyboard: [][]models.InlineKeyboardButton{{
+						{Text: i18n.T(lang, i18n.On), CallbackData: "/set_last_call on"},
+						{Text: i18n.T(lang, i18n.Off), CallbackData: "/set_last_call off"},
+					}},
 				},
 				ReplyToMessageID: update.Message.ID,
 			})
 		}
 		if update.Message.Text == "/post" {
-			activities, err := a.storage.UserActivities(ctx, update.Message.From.ID)
+			overview, err := a.storage.UserOverview(ctx, update.Message.From.ID)
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось получить список марафонов пользователя @%s: %v\n"+
-						"Используй команду /start - чтобы участвовать в марафонах",
+					Text: i18n.T(lang, i18n.ActivitiesListFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
-			keyboard := &models.InlineKeyboardMarkup{
-				InlineKeyboard: make([][]models.InlineKeyboardButton, 0, len(activities)+1),
-			}
-			for _, activity := range activities {
-				keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
-					{Text: fmt.Sprintf("%q+1", activity), CallbackData: "/post " + activity},
-				})
-			}
-			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
-				{Text: "Новый марафон", CallbackData: "/add"},
-			})
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID:                   update.Message.Chat.ID,
-				Text:                     "Записать участие в марафоне",
+				Text:                     i18n.T(lang, i18n.PostPrompt),
 				AllowSendingWithoutReply: true,
-				ReplyMarkup:              keyboard,
+				ReplyMarkup:              postKeyboard(lang, overview.Marathons),
 				ReplyToMessageID:         update.Message.ID,
 			})
 		}
+		if update.Message.Text == "/undo" {
+			activity, err := a.storage.UndoLastUserPost(ctx, update.Message.From.ID)
+			if err != nil {
+				text := i18n.T(lang, i18n.UndoPostFailed, explain(ctx, lang, err))
+				if errors.Is(err, storage.ErrNothingToUndo) {
+					text = i18n.T(lang, i18n.UndoPostNothing,
+						env.UndoWindowMinutes(), i18n.N(lang, i18n.MinutesGenitive, env.UndoWindowMinutes()),
+					)
+				}
+				return b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:           update.Message.Chat.ID,
+					Text:             text,
+					ReplyToMessageID: update.Message.ID,
+				})
+			}
+			committed(ctx)
+			return b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:           update.Message.Chat.ID,
+				Text:             i18n.T(lang, i18n.PostUndone, activity.Name),
+				ReplyToMessageID: update.Message.ID,
+			})
+		}
 		if update.Message.Text == "/remove" {
 			activities, err := a.storage.UserActivities(ctx, update.Message.From.ID)
 			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось получить список марафонов пользователя @%s: %v\n"+
-						"Используй команду /start - чтобы участвовать в марафонах",
+					Text: i18n.T(lang, i18n.ActivitiesListFailed,
 						update.Message.From.Username,
-						err,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
-			keyboard := &models.InlineKeyboardMarkup{
-				InlineKeyboard: make([][]models.InlineKeyboardButton, 0, len(activities)+1),
-			}
-			for _, activity := range activities {
-				keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
-					{Text: activity, CallbackData: "/remove " + activity},
-				})
-			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID:                   update.Message.Chat.ID,
-				Text:                     "Больше не хочу участвовать в марафоне",
+				Text:                     i18n.T(lang, i18n.RemovePrompt),
 				AllowSendingWithoutReply: true,
-				ReplyMarkup:              keyboard,
+				ReplyMarkup:              activitiesKeyboard("/remove", activities),
 				ReplyToMessageID:         update.Message.ID,
 			})
 		}
-		if update.Message.ReplyToMessage != nil {
-			if update.Message.ReplyToMessage.Text == enterActivityName {
-				activity := update.Message.Text
-				err := a.storage.NewUserActivity(ctx, update.Message.From.ID, activity)
-				if err != nil {
-					return b.SendMessage(ctx, &bot.SendMessageParams{
-						ChatID: update.Message.Chat.ID,
-						Text: fmt.Sprintf("Не удалось сохранить участие в марафоне %q пользователя @%s: %v",
-							activity,
-							update.Message.From.Username,
-							err,
-						),
-						ReplyToMessageID: update.Message.ID,
-					})
-				}
+		if update.Message.Text == "/add" {
+			return a.startConversation(ctx, b, lang, update.Message.Chat.ID, update.Message.From.ID,
+				conversation.Create, "", i18n.T(lang, i18n.EnterActivityName),
+			)
+		}
+		if update.Message.Text == "/import" {
+			return a.startConversation(ctx, b, lang, update.Message.Chat.ID, update.Message.From.ID,
+				conversation.Import, "", i18n.T(lang, i18n.EnterActivitiesList),
+			)
+		}
+		if update.Message.Text == "/rename" || update.Message.Text == "/target" {
+			activities, err := a.storage.UserActivities(ctx, update.Message.From.ID)
+			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: update.Message.Chat.ID,
-					Text: fmt.Sprintf("Ок, теперь @%s участвует в марафоне %q\n"+
-						"Используй команду /post - чтобы записать участие в марафоне",
+					Text: i18n.T(lang, i18n.ActivitiesListFailed,
 						update.Message.From.Username,
-						activity,
+						explain(ctx, lang, err),
 					),
 					ReplyToMessageID: update.Message.ID,
 				})
 			}
-		}
-	}
-	if update.CallbackQuery != nil {
-		if update.CallbackQuery.Data == "/add" {
+			text := i18n.T(lang, i18n.ChooseRename)
+			if update.Message.Text == "/target" {
+				text = i18n.T(lang, i18n.ChooseTarget)
+			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID:           update.CallbackQuery.Message.Chat.ID,
-				Text:             enterActivityName,
-				ReplyToMessageID: update.CallbackQuery.Message.ID,
+				ChatID:                   update.Message.Chat.ID,
+				Text:                     text,
+				AllowSendingWithoutReply: true,
+				ReplyMarkup:              activitiesKeyboard(update.Message.Text, activities),
+				ReplyToMessageID:         update.Message.ID,
 			})
 		}
-		if strings.HasPrefix(update.CallbackQuery.Data, "/post ") {
-			activity := strings.TrimLeft(update.CallbackQuery.Data, "/post ")
-			if err := a.storage.PostUserActivity(ctx, update.CallbackQuery.Sender.ID, activity); err != nil {
+		if update.Message.Text == "/archive" || update.Message.Text == "/restore" {
+			list, text := a.storage.UserActivities, i18n.T(lang, i18n.ChooseArchive)
+			if update.Message.Text == "/restore" {
+				list, text = a.storage.UserArchivedActivities, i18n.T(lang, i18n.ChooseRestore)
+			}
+			activities, err := list(ctx, update.Message.From.ID)
+			if err != nil {
 				return b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: update.CallbackQuery.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось сохранить участие в марафоне %q пользователя @%s: %v",
-						activity,
-						update.CallbackQuery.Sender.Username,
-						err,
+					ChatID: update.Message.Chat.ID,
+					Text: i18n.T(lang, i18n.ActivitiesListFailed,
+						update.Message.From.Username,
+						explain(ctx, lang, err),
 					),
-					ReplyToMessageID: update.CallbackQuery.Message.ID,
+					ReplyToMessageID: update.Message.ID,
+				})
+			}
+			if len(activities) == 0 {
+				return b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:           update.Message.Chat.ID,
+					Text:             i18n.T(lang, i18n.NoActivities),
+					ReplyToMessageID: update.Message.ID,
 				})
 			}
 			return b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.CallbackQuery.Message.Chat.ID,
-				Text: fmt.Sprintf("Активность %q успешна сохранена для @%s\n"+
-					"Используй команду /stats - чтобы посмотреть статистику марафонов",
-					activity,
-					update.CallbackQuery.Sender.Username,
-				),
-				ReplyToMessageID: update.CallbackQuery.Message.ID,
+				ChatID:                   update.Message.Chat.ID,
+				Text:                     text,
+				AllowSendingWithoutReply: true,
+				ReplyMarkup:              activitiesKeyboard(update.Message.Text, activities),
+				ReplyToMessageID:         update.Message.ID,
 			})
 		}
-		if strings.HasPrefix(update.CallbackQuery.Data, "/remove ") {
-			activity := strings.TrimLeft(update.CallbackQuery.Data, "/remove ")
-			err := a.storage.DeleteUserActivity(ctx, update.CallbackQuery.Sender.ID, activity)
+		if !strings.HasPrefix(update.Message.Text, "/") {
+			return a.converse(ctx, b, lang, update.Message)
+		}
+	}
+	if update.CallbackQuery != nil {
+		query := update.CallbackQuery
+		lang := a.language(ctx, &query.Sender)
+		if strings.HasPrefix(query.Data, "/language ") {
+			l, ok := i18n.Parse(strings.TrimPrefix(query.Data, "/language "))
+			if !ok {
+				return nil, toast(ctx, b, query, "")
+			}
+			if err := a.storage.SetUserLanguage(ctx, query.Sender.ID, string(l)); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.LanguageFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(l, i18n.Saved))
+			return replace(ctx, b, query.Message, i18n.T(l, i18n.LanguageSet), nil)
+		}
+		if query.Data == "/add" {
+			_ = toast(ctx, b, query, "")
+			return a.startConversation(ctx, b, lang, query.Message.Chat.ID, query.Sender.ID,
+				conversation.Create, "", i18n.T(lang, i18n.EnterActivityName),
+			)
+		}
+		if strings.HasPrefix(query.Data, "/rename ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
 			if err != nil {
-				return b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: update.CallbackQuery.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось удалить марафон %q пользователя @%s: %v",
-						activity,
-						update.CallbackQuery.Sender.Username,
-						err,
-					),
-					ReplyToMessageID: update.CallbackQuery.Message.ID,
-				})
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
 			}
-			return b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.CallbackQuery.Message.Chat.ID,
-				Text: fmt.Sprintf("Ок, теперь @%s больше не участвует в марафоне %q\n"+
-					"Используй команду /stats - чтобы посмотреть статистику марафонов",
-					update.CallbackQuery.Sender.Username,
-					activity,
-				),
-				ReplyToMessageID: update.CallbackQuery.Message.ID,
+			_ = toast(ctx, b, query, "")
+			return a.startConversation(ctx, b, lang, query.Message.Chat.ID, query.Sender.ID,
+				conversation.Rename, strconv.FormatUint(activity.ID, 10), i18n.T(lang, i18n.EnterActivityRename, activity.Name),
+			)
+		}
+		if strings.HasPrefix(query.Data, "/target ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			_ = toast(ctx, b, query, "")
+			return a.startConversation(ctx, b, lang, query.Message.Chat.ID, query.Sender.ID,
+				conversation.Target, strconv.FormatUint(activity.ID, 10), i18n.T(lang, i18n.EnterActivityTarget, activity.Name),
+			)
+		}
+		if strings.HasPrefix(query.Data, "/post ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			if err := a.storage.PostUserActivity(ctx, query.Sender.ID, activity.ID); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.PostFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.PostedToast, activity.Name))
+			overview, err := a.storage.UserOverview(ctx, query.Sender.ID)
+			if err != nil {
+				return nil, err
+			}
+			keyboard := postKeyboard(lang, overview.Marathons)
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+				{Text: i18n.T(lang, i18n.UndoPostButton), CallbackData: "/undo_post"},
 			})
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.PostPromptStats), keyboard)
 		}
-		if strings.HasPrefix(update.CallbackQuery.Data, "/set_rotate_hour ") {
-			hour, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "/set_rotate_hour "))
+		if query.Data == "/undo_post" {
+			activity, err := a.storage.UndoLastUserPost(ctx, query.Sender.ID)
 			if err != nil {
-				return b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: update.CallbackQuery.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось распарсить параметр %q команды /set_rotate_hour в число: %v",
-						strings.TrimPrefix(update.CallbackQuery.Data, "/set_rotate_hour "),
-						err,
-					),
-					ReplyToMessageID: update.CallbackQuery.Message.ID,
-				})
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.UndoPostFailed,
+					explain(ctx, lang, err),
+				))
 			}
-			if hour < 0 || hour > 23 {
-				return b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: update.CallbackQuery.Message.Chat.ID,
-					Text: fmt.Sprintf("Недопустимое значение параметра %d.\n"+
-						"Параметр команды /set_rotate_hour должен быть числом от 0 до 23",
-						hour,
-					),
-					ReplyToMessageID: update.CallbackQuery.Message.ID,
-				})
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.PostUndoneToast, activity.Name))
+			overview, err := a.storage.UserOverview(ctx, query.Sender.ID)
+			if err != nil {
+				return nil, err
 			}
-			if err := a.storage.SetUserRotateHour(ctx, update.CallbackQuery.Sender.ID, int32(hour)); err != nil {
-				return b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: update.CallbackQuery.Message.Chat.ID,
-					Text: fmt.Sprintf("Не удалось установить время ежедневной ротации статистики пользователя @%s: %v",
-						update.CallbackQuery.Sender.Username,
-						err,
-					),
-					ReplyToMessageID: update.CallbackQuery.Message.ID,
-				})
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.PostUndonePrompt,
+				activity.Name,
+			), postKeyboard(lang, overview.Marathons))
+		}
+		if strings.HasPrefix(query.Data, "/remove ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
 			}
-			return b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.CallbackQuery.Message.Chat.ID,
-				Text: fmt.Sprintf("Время ежедневной ротации статистики пользователя @%s установлено в %d:00 UTC",
-					update.CallbackQuery.Sender.Username,
-					hour,
-				),
-				ReplyToMessageID: update.CallbackQuery.Message.ID,
+			if err := a.storage.DeleteUserActivity(ctx, query.Sender.ID, activity.ID); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.RemoveFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.RemovedToast, activity.Name))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.Removed,
+				query.Sender.Username,
+				activity.Name,
+				env.UndoWindowMinutes(), i18n.N(lang, i18n.Minutes, env.UndoWindowMinutes()),
+			), &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{{
+					{Text: i18n.T(lang, i18n.UndoRemoveButton), CallbackData: "/undo_remove " + strconv.FormatUint(activity.ID, 10)},
+				}},
 			})
 		}
+		if strings.HasPrefix(query.Data, "/undo_remove ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			if err := a.storage.UndoDeleteUserActivity(ctx, query.Sender.ID, activity.ID); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.UndoRemoveFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.RemoveUndoneToast, activity.Name))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.RemoveUndone, activity.Name), nil)
+		}
+		if strings.HasPrefix(query.Data, "/archive ") || strings.HasPrefix(query.Data, "/restore ") {
+			archived := strings.HasPrefix(query.Data, "/archive ")
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			if err := a.storage.SetUserActivityArchived(ctx, query.Sender.ID, activity.ID, archived); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.ArchiveFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			if archived {
+				_ = toast(ctx, b, query, i18n.T(lang, i18n.ArchivedToast, activity.Name))
+				return replace(ctx, b, query.Message, i18n.T(lang, i18n.Archived, activity.Name), nil)
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.RestoredToast, activity.Name))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.Restored, activity.Name), nil)
+		}
+		if strings.HasPrefix(query.Data, "/set_rotate_hour ") {
+			hour, err := strconv.Atoi(strings.TrimPrefix(query.Data, "/set_rotate_hour "))
+			if err != nil || hour < 0 || hour > 23 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidHour,
+					strings.TrimPrefix(query.Data, "/set_rotate_hour "),
+					"/set_rotate_hour",
+				))
+			}
+			if err := a.storage.SetUserRotateHour(ctx, query.Sender.ID, int32(hour)); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.RotateHourFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.HourSetToast, hour))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.RotateHourSet,
+				query.Sender.Username,
+				hour,
+			), nil)
+		}
+		if strings.HasPrefix(query.Data, "/remind_post ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			if err := a.storage.PostUserActivity(ctx, query.Sender.ID, activity.ID); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.PostFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.PostedToast, activity.Name))
+			return a.refreshReminder(ctx, b, lang, query.Message, query.Sender.ID,
+				i18n.T(lang, i18n.Posted, activity.Name), true,
+			)
+		}
+		if strings.HasPrefix(query.Data, "/pause ") {
+			activity, err := a.callbackActivity(ctx, query.Sender.ID, query.Data)
+			if err != nil {
+				return nil, alert(ctx, b, query, explain(ctx, lang, err))
+			}
+			if err := a.storage.PauseUserActivity(ctx, query.Sender.ID, activity.ID); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.PauseFailed,
+					activity.Name,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.PausedToast, activity.Name))
+			return a.refreshReminder(ctx, b, lang, query.Message, query.Sender.ID,
+				i18n.T(lang, i18n.Paused, activity.Name), false,
+			)
+		}
+		if query.Data == "/skip" {
+			left, err := a.storage.FreezeUserActivities(ctx, query.Sender.ID)
+			if err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.SkipFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.SkippedToast))
+			return a.refreshReminder(ctx, b, lang, query.Message, query.Sender.ID,
+				i18n.T(lang, i18n.Skipped, left), false,
+			)
+		}
+		if strings.HasPrefix(query.Data, "/snooze ") {
+			d, err := time.ParseDuration(strings.TrimPrefix(query.Data, "/snooze "))
+			if err != nil || d <= 0 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidSnooze,
+					strings.TrimPrefix(query.Data, "/snooze "),
+				))
+			}
+			until := time.Now().UTC().Add(d)
+			if err := a.storage.SnoozeUser(ctx, query.Sender.ID, until); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.SnoozeFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.SnoozedToast))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.SnoozedUntil, until.Format("15:04")), nil)
+		}
+		if query.Data == "/snooze_until" {
+			_ = toast(ctx, b, query, "")
+			return b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+				ChatID:      query.Message.Chat.ID,
+				MessageID:   query.Message.ID,
+				ReplyMarkup: hoursKeyboard("/snooze_at "),
+			})
+		}
+		if strings.HasPrefix(query.Data, "/snooze_at ") {
+			hour, err := strconv.Atoi(strings.TrimPrefix(query.Data, "/snooze_at "))
+			if err != nil || hour < 0 || hour > 23 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidHour,
+					strings.TrimPrefix(query.Data, "/snooze_at "),
+					"/snooze_at",
+				))
+			}
+			now := time.Now().UTC()
+			until := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+			if !until.After(now) {
+				until = until.Add(24 * time.Hour)
+			}
+			if err := a.storage.SnoozeUser(ctx, query.Sender.ID, until); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.SnoozeFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			committed(ctx)
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.SnoozedToast))
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.SnoozedUntil, until.Format("15:04")), nil)
+		}
+		if strings.HasPrefix(query.Data, "/digest_weekly ") {
+			args := strings.Fields(strings.TrimPrefix(query.Data, "/digest_weekly "))
+			if len(args) == 0 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidWeekday, ""))
+			}
+			weekday, err := strconv.Atoi(args[0])
+			if err != nil || weekday < digest.Off || weekday > 6 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidWeekday,
+					args[0],
+				))
+			}
+			if weekday != digest.Off && len(args) == 1 {
+				_ = toast(ctx, b, query, "")
+				return replace(ctx, b, query.Message, i18n.T(lang, i18n.ChooseDigestHour),
+					hoursKeyboard(fmt.Sprintf("/digest_weekly %d ", weekday)),
+				)
+			}
+			hour := 0
+			if len(args) > 1 {
+				hour, err = strconv.Atoi(args[1])
+				if err != nil || hour < 0 || hour > 23 {
+					return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidDigestHour,
+						args[1],
+					))
+				}
+			}
+			if err := a.storage.SetUserWeeklyDigest(ctx, query.Sender.ID, int32(weekday), int32(hour)); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.WeeklyDigestFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.Saved))
+			if weekday == digest.Off {
+				return replace(ctx, b, query.Message, i18n.T(lang, i18n.WeeklyDigestOff), nil)
+			}
+			return replace(ctx, b, query.Message,
+				i18n.T(lang, i18n.WeeklyDigestSet, i18n.T(lang, i18n.Weekdays[weekday]), hour),
+				nil,
+			)
+		}
+		if strings.HasPrefix(query.Data, "/digest_monthly ") {
+			enabled := strings.TrimPrefix(query.Data, "/digest_monthly ") == "on"
+			if err := a.storage.SetUserMonthlyDigest(ctx, query.Sender.ID, enabled); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.MonthlyDigestFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.Saved))
+			text := i18n.T(lang, i18n.MonthlyDigestOff)
+			if enabled {
+				text = i18n.T(lang, i18n.MonthlyDigestOn)
+			}
+			return replace(ctx, b, query.Message, text, nil)
+		}
+		if strings.HasPrefix(query.Data, "/set_remind_hour ") {
+			hour, err := strconv.Atoi(strings.TrimPrefix(query.Data, "/set_remind_hour "))
+			if err != nil || hour < reminder.Off || hour > 23 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidHour,
+					strings.TrimPrefix(query.Data, "/set_remind_hour "),
+					"/set_remind_hour",
+				))
+			}
+			if err := a.storage.SetUserRemindHour(ctx, query.Sender.ID, int32(hour)); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.RemindHourFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.Saved))
+			if hour == reminder.Off {
+				return replace(ctx, b, query.Message, i18n.T(lang, i18n.RemindHourOff,
+					query.Sender.Username,
+				), nil)
+			}
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.RemindHourSet,
+				query.Sender.Username,
+				hour,
+			), nil)
+		}
+		if strings.HasPrefix(query.Data, "/set_firm_remind ") {
+			hours, err := strconv.Atoi(strings.TrimPrefix(query.Data, "/set_firm_remind "))
+			if err != nil || hours < 0 || hours > 23 {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.InvalidHour,
+					strings.TrimPrefix(query.Data, "/set_firm_remind "),
+					"/set_firm_remind",
+				))
+			}
+			if err := a.storage.SetUserFirmRemindHours(ctx, query.Sender.ID, int32(hours)); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.FirmRemindFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.Saved))
+			if hours == 0 {
+				return replace(ctx, b, query.Message, i18n.T(lang, i18n.FirmRemindOff,
+					query.Sender.Username,
+				), nil)
+			}
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.FirmRemindSet,
+				query.Sender.Username,
+				hours, i18n.N(lang, i18n.Hours, hours),
+			), nil)
+		}
+		if strings.HasPrefix(query.Data, "/set_last_call ") {
+			enabled := strings.TrimPrefix(query.Data, "/set_last_call ") == "on"
+			if err := a.storage.SetUserLastCallRemind(ctx, query.Sender.ID, enabled); err != nil {
+				return nil, alert(ctx, b, query, i18n.T(lang, i18n.LastCallFailed,
+					explain(ctx, lang, err),
+				))
+			}
+			_ = toast(ctx, b, query, i18n.T(lang, i18n.Saved))
+			state := i18n.T(lang, i18n.Disabled)
+			if enabled {
+				state = i18n.T(lang, i18n.Enabled)
+			}
+			return replace(ctx, b, query.Message, i18n.T(lang, i18n.LastCallSet,
+				query.Sender.Username,
+				state,
+			), nil)
+		}
+		return nil, toast(ctx, b, query, "")
 	}
 	return nil, nil
 }
+
+func hoursKeyboard(prefix string) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, 4)
+	for i := 0; i < 4; i++ {
+		row := make([]models.InlineKeyboardButton, 0, 6)
+		for j := 0; j < 6; j++ {
+			h := i*6 + j
+			row = append(row, models.InlineKeyboardButton{
+				Text: strconv.Itoa(h), CallbackData: prefix + strconv.Itoa(h),
+			})
+		}
+		rows = append(rows, row)
+	}
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+// reminderKeyboard is the actionable keyboard of reminder messages.
+func reminderKeyboard(lang i18n.Lang, pending []storage.Marathon) *models.InlineKeyboardMarkup {
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: make([][]models.InlineKeyboardButton, 0, len(pending)+1),
+	}
+	for _, activity := range pending {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%q+1", activity.Name), CallbackData: "/remind_post " + strconv.FormatUint(activity.ID, 10)},
+			{Text: i18n.T(lang, i18n.PauseButton), CallbackData: "/pause " + strconv.FormatUint(activity.ID, 10)},
+		})
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.Snooze15mButton), CallbackData: "/snooze 15m"},
+		{Text: i18n.T(lang, i18n.Snooze1hButton), CallbackData: "/snooze 1h"},
+		{Text: i18n.T(lang, i18n.Snooze3hButton), CallbackData: "/snooze 3h"},
+		{Text: i18n.T(lang, i18n.SnoozeAtButton), CallbackData: "/snooze_until"},
+	}, []models.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.SkipButton), CallbackData: "/skip"},
+	})
+	return keyboard
+}
+
+func postKeyboard(lang i18n.Lang, marathons []storage.Marathon) *models.InlineKeyboardMarkup {
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: make([][]models.InlineKeyboardButton, 0, len(marathons)+1),
+	}
+	for _, activity := range marathons {
+		text := fmt.Sprintf("%q+1", activity.Name)
+		if activity.Posted() {
+			text = "✅ " + text
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: text, CallbackData: "/post " + strconv.FormatUint(activity.ID, 10)},
+		})
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.NewActivity), CallbackData: "/add"},
+	})
+	return keyboard
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"marathon_procrastination_bot/internal/conversation"
+	"marathon_procrastination_bot/internal/storage"
+)
+
+var errUnavailable = errors.New("storage is unavailable")
+
+// fakeStorage records changes and claims of updates. Methods which are not
+// overridden panic, so the test fails if the handler reaches them.
+type fakeStorage struct {
+	Storage
+
+	posts, undos, freezes int
+	released              bool
+}
+
+func (s *fakeStorage) ClaimUpdate(context.Context, int64) (bool, error) { return true, nil }
+
+func (s *fakeStorage) ReleaseUpdate(context.Context, int64) error {
+	s.released = true
+	return nil
+}
+
+func (s *fakeStorage) ActivateUser(context.Context, int64) (bool, error) { return false, nil }
+
+func (s *fakeStorage) UserLanguage(context.Context, int64) (string, error) { return "en", nil }
+
+func (s *fakeStorage) UserActivity(_ context.Context, _ int64, id uint64) (storage.Activity, error) {
+	return storage.Activity{ID: id, Name: "run"}, nil
+}
+
+func (s *fakeStorage) PostUserActivity(context.Context, int64, uint64) error {
+	s.posts++
+	return nil
+}
+
+func (s *fakeStorage) UndoLastUserPost(context.Context, int64) (storage.Activity, error) {
+	s.undos++
+	return storage.Activity{ID: 1, Name: "run"}, nil
+}
+
+func (s *fakeStorage) FreezeUserActivities(context.Context, int64) (uint32, error) {
+	s.freezes++
+	return 2, nil
+}
+
+func (s *fakeStorage) UserOverview(context.Context, int64) (storage.Overview, error) {
+	return storage.Overview{}, errUnavailable
+}
+
+func (s *fakeStorage) UserConversation(context.Context, int64) (conversation.State, bool, error) {
+	return conversation.State{}, false, errUnavailable
+}
+
+// testBot answers every Bot API call successfully.
+func testBot(t *testing.T) *bot.Bot {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	t.Cleanup(server.Close)
+	b, err := bot.New("token", bot.WithSkipGetMe(), bot.WithServerURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func callback(data string) *models.Update {
+	return &models.Update{ID: 1, CallbackQuery: &models.CallbackQuery{
+		ID:      "query",
+		Sender:  models.User{ID: 42},
+		Message: &models.Message{ID: 7, Chat: models.Chat{ID: 42}},
+		Data:    data,
+	}}
+}
+
+func TestHandleKeepsClaimAfterCommit(t *testing.T) {
+	tests := []struct {
+		name   string
+		update *models.Update
+		// changes counts the changes made by the update
+		changes func(s *fakeStorage) int
+	}{
+		{"post from the prompt", callback("/post 1"), func(s *fakeStorage) int { return s.posts }},
+		{"undo from the prompt", callback("/undo_post"), func(s *fakeStorage) int { return s.undos }},
+		{"post from the reminder", callback("/remind_post 1"), func(s *fakeStorage) int { return s.posts }},
+		{"skip from the reminder", callback("/skip"), func(s *fakeStorage) int { return s.freezes }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeStorage{}
+			a := &Agent{storage: s}
+			if _, err := a.Handle(context.Background(), testBot(t), tt.update); err != nil {
+				t.Errorf("Handle() = %v, want the failure of the overview only logged", err)
+			}
+			if s.released {
+				t.Error("the update is released, its redelivery would apply the change again")
+			}
+			if got := tt.changes(s); got != 1 {
+				t.Errorf("changes = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestHandleReleasesClaimBeforeCommit(t *testing.T) {
+	s := &fakeStorage{}
+	a := &Agent{storage: s}
+	update := &models.Update{ID: 1, Message: &models.Message{
+		ID:   7,
+		From: &models.User{ID: 42},
+		Chat: models.Chat{ID: 42},
+		Text: "/cancel",
+	}}
+	if _, err := a.Handle(context.Background(), testBot(t), update); !errors.Is(err, errUnavailable) {
+		t.Errorf("Handle() = %v, want %v", err, errUnavailable)
+	}
+	if !s.released {
+		t.Error("the update is not released, its redelivery would be skipped")
+	}
+}
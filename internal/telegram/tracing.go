@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"marathon_procrastination_bot/internal/tracing"
+)
+
+// pollTimeout is the default of the bot library, kept along with its client.
+const pollTimeout = time.Minute
+
+// tracedClient traces every Bot API call. The URL contains the token of the
+// bot, so spans are named by the method only and errors of the transport,
+// which quote the URL, are recorded without it.
+type tracedClient struct {
+	client *http.Client
+}
+
+func (c tracedClient) Do(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	ctx, span := tracing.Start(req.Context(), "telegram."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("telegram.method", method)),
+	)
+	defer span.End()
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			span.RecordError(urlErr.Err)
+			span.SetStatus(codes.Error, urlErr.Err.Error())
+		} else {
+			span.SetStatus(codes.Error, "request failed")
+		}
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,136 @@
+// Package tracing traces updates, storage methods and Bot API calls with
+// OpenTelemetry. Spans are batched and exported by OTEL_TRACES_EXPORTER: by
+// OTLP/HTTP to the collector configured by OTEL_EXPORTER_OTLP_* variables, or
+// to stdout.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"marathon_procrastination_bot/internal/env"
+)
+
+// scope is the instrumentation scope of spans of the bot.
+const scope = "marathon_procrastination_bot"
+
+var (
+	setup    sync.Once
+	provider atomic.Pointer[sdktrace.TracerProvider]
+)
+
+// Setup makes Tracer export spans by OTEL_TRACES_EXPORTER.
+// Until then, and with the none exporter, spans are not recorded.
+// Subsequent calls do nothing.
+func Setup() {
+	setup.Do(func() {
+		exporter, err := newExporter(context.Background(), env.TracesExporter())
+		if err != nil {
+			slog.Error("setup tracing", "error", err)
+			return
+		}
+		if exporter == nil {
+			return
+		}
+		p := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(env.ServiceName()))),
+		)
+		otel.SetTracerProvider(p)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+		provider.Store(p)
+	})
+}
+
+// Tracer is the tracer of the bot.
+func Tracer() trace.Tracer {
+	if p := provider.Load(); p != nil {
+		return p.Tracer(scope)
+	}
+	return noop.NewTracerProvider().Tracer(scope)
+}
+
+// Start starts the span by the tracer of the bot.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, options...)
+}
+
+// End ends the span, marking it failed by err if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Attributes converts key-value pairs, as passed to slog, to span attributes.
+func Attributes(args ...any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		switch v := args[i+1].(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
+
+// Flush exports ended spans right away, e.g. before the serverless function
+// is frozen after the response.
+func Flush(ctx context.Context) error {
+	if p := provider.Load(); p != nil {
+		return p.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// Shutdown stops periodic export and exports the remaining spans.
+func Shutdown(ctx context.Context) error {
+	if p := provider.Load(); p != nil {
+		return p.Shutdown(ctx)
+	}
+	return nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	case "console", "stdout":
+		return stdouttrace.New()
+	}
+	return nil, fmt.Errorf("unknown %s %q", env.OTEL_TRACES_EXPORTER, name)
+}
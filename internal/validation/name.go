@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the maximal length of an activity name in characters.
+// Names are shown on inline buttons, which are truncated by Telegram clients.
+const MaxNameLength = 64
+
+var (
+	ErrNameEmpty   = errors.New("activity name is empty")
+	ErrNameTooLong = errors.New("activity name is too long")
+	ErrNameCommand = errors.New("activity name looks like a command")
+	ErrNameCharset = errors.New("activity name contains invalid characters")
+)
+
+// ActivityName validates the name of an activity and returns it normalized:
+// trimmed, with runs of whitespace collapsed into single spaces.
+func ActivityName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", ErrNameEmpty
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", ErrNameTooLong
+	}
+	if strings.HasPrefix(name, "/") {
+		return "", ErrNameCommand
+	}
+	// Format characters are invisible, except the zero width joiner of emoji sequences.
+	for _, r := range name {
+		if r == utf8.RuneError || unicode.IsControl(r) || (unicode.Is(unicode.Cf, r) && r != '\u200d') {
+			return "", ErrNameCharset
+		}
+	}
+	return name, nil
+}
+
+// Key returns the form of the name in which duplicates are compared:
+// names differing only in case and whitespace are the same activity.
+func Key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
@@ -2,17 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"marathon_procrastination_bot/internal/env"
+	"marathon_procrastination_bot/internal/logging"
+	"marathon_procrastination_bot/internal/metrics"
+	"marathon_procrastination_bot/internal/scheduler"
 	"marathon_procrastination_bot/internal/storage"
 	"marathon_procrastination_bot/internal/telegram"
+	"marathon_procrastination_bot/internal/tracing"
+	"net/http"
 	"os"
 	"os/signal"
-	"time"
 )
 
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	logging.Setup()
+	tracing.Setup()
+	defer func() {
+		if err := tracing.Shutdown(context.Background()); err != nil {
+			slog.Error("export spans", "error", err)
+		}
+	}()
+
 	s, err := storage.New(ctx)
 	if err != nil {
 		panic(err)
@@ -27,38 +42,28 @@ func main() {
 		panic(err)
 	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(-time.Since(time.Unix(int64(time.Now().UnixMilli()/1000/60/60+1)*60*60, 0))):
-				func() {
-					ids, err := s.UsersForRotate(ctx, int32(time.Unix(int64(time.Now().UnixMilli()/1000/60/60)*60*60, 0).UTC().Hour()))
-					if err != nil {
-						return
-					}
-					for _, id := range ids {
-						if err := s.RotateUserStats(ctx, id); err != nil {
-							return
-						}
-					}
-				}()
-			case <-time.After(time.Second):
-				func() {
-					ids, err := s.UsersForNotification(ctx)
-					if err != nil {
-						return
-					}
-					for _, id := range ids {
-						if err := agent.PingUser(ctx, id); err != nil {
-							return
-						}
-					}
-				}()
+	if addr := env.MetricsAddr(); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		server := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("serve metrics", "addr", addr, "error", err)
 			}
-		}
+		}()
+		defer func() {
+			_ = server.Shutdown(context.Background())
+		}()
+	}
+
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		scheduler.New(scheduler.Jobs(s, agent, agent.Outbox())...).Run(ctx)
 	}()
 
+	slog.Info("bot started")
 	agent.Bot().Start(ctx)
+
+	<-jobs
 }